@@ -0,0 +1,67 @@
+package accounts
+
+import (
+	"context"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// AccountsAPI is every operation on the accounts api, with and without a context. *Service implements it, so code
+// that depends on AccountsAPI rather than on the package level functions can be handed DefaultService (or any other
+// Service) in production and a FakeAccountsAPI in its unit tests
+type AccountsAPI interface {
+	Create(acc models.Account) (*models.Account, *client.Response, error)
+	CreateWithCtx(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error)
+	Fetch(id string) (*models.Account, *client.Response, error)
+	FetchWithCtx(ctx context.Context, id string) (*models.Account, *client.Response, error)
+	Update(id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error)
+	UpdateWithCtx(ctx context.Context, id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error)
+	Delete(id string, version int) error
+	DeleteWithCtx(ctx context.Context, id string, version int) error
+
+	ListPage(opts ListOptions) (*models.AccountList, *client.Response, error)
+	ListPageWithCtx(ctx context.Context, opts ListOptions) (*models.AccountList, *client.Response, error)
+	List(opts ListOptions) *AccountIterator
+	ListWithCtx(ctx context.Context, opts ListOptions) *AccountIterator
+	Find(filter AccountFilter) ([]models.Account, error)
+	FindWithCtx(ctx context.Context, filter AccountFilter) ([]models.Account, error)
+	FindByIBAN(iban string) ([]models.Account, error)
+	FindByIBANWithCtx(ctx context.Context, iban string) ([]models.Account, error)
+	FindByAccountNumber(accountNumber string) ([]models.Account, error)
+	FindByAccountNumberWithCtx(ctx context.Context, accountNumber string) ([]models.Account, error)
+
+	ResolveCreate(acc models.Account) (*CreateResolution, error)
+	ResolveCreateWithCtx(ctx context.Context, acc models.Account) (*CreateResolution, error)
+	CreateOrGet(acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error)
+	CreateOrGetWithCtx(ctx context.Context, acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error)
+	DeleteLatest(id string, opts DeleteOptions) error
+	DeleteLatestWithCtx(ctx context.Context, id string, opts DeleteOptions) error
+
+	// the bulk operations always take a context, as they are the ones most worth cancelling
+	BulkCreate(ctx context.Context, accs []models.Account, opts BulkOptions) *BulkReport
+	BulkCreateFrom(ctx context.Context, accs <-chan models.Account, opts BulkOptions) *BulkReport
+	BulkFetch(ctx context.Context, ids []string, opts BulkOptions) *BulkReport
+	BulkFetchFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport
+	BulkDelete(ctx context.Context, ids []string, opts BulkOptions) *BulkReport
+	BulkDeleteFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport
+
+	// so is the health check
+	Health(ctx context.Context) (*client.HealthCheck, error)
+	WaitUntilReady(ctx context.Context, pollInterval time.Duration) error
+}
+
+var _ AccountsAPI = (*Service)(nil)
+
+// returns an iterator over a fixed set of accounts that ends with err, which may be nil. it never fetches anything,
+// which makes it handy for fakes of AccountsAPI
+func NewAccountIterator(accs []models.Account, err error) *AccountIterator {
+	page := &models.AccountList{Data: []models.AccountData{}}
+	for _, acc := range accs {
+		if acc.Data != nil {
+			page.Data = append(page.Data, *acc.Data)
+		}
+	}
+	return &AccountIterator{ctx: context.Background(), page: page, index: -1, nextErr: err}
+}
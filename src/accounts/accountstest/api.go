@@ -0,0 +1,203 @@
+package accountstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sarabrajsingh/interview-accountapi/src/accounts/internal/memstore"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// routes a request to the endpoint it is for
+func (s *Server) serveAPI(writer http.ResponseWriter, req *http.Request) {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case path == HealthPath && req.Method == http.MethodGet:
+		writeJSON(writer, http.StatusOK, map[string]string{"status": "up"})
+	case path == AccountsPath && req.Method == http.MethodPost:
+		s.create(writer, req)
+	case path == AccountsPath && req.Method == http.MethodGet:
+		s.list(writer, req)
+	case strings.HasPrefix(path, AccountsPath+"/") && !strings.Contains(path[len(AccountsPath)+1:], "/"):
+		id := path[len(AccountsPath)+1:]
+		switch req.Method {
+		case http.MethodGet:
+			s.fetch(writer, id)
+		case http.MethodPatch:
+			s.patch(writer, req, id)
+		case http.MethodDelete:
+			s.delete(writer, req, id)
+		default:
+			writeError(writer, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	default:
+		writeError(writer, http.StatusNotFound, "route not found")
+	}
+}
+
+// POST /v1/organisation/accounts
+func (s *Server) create(writer http.ResponseWriter, req *http.Request) {
+	acc := models.Account{}
+	if err := json.NewDecoder(req.Body).Decode(&acc); err != nil || acc.Data == nil {
+		writeError(writer, http.StatusBadRequest, "invalid account data")
+		return
+	}
+	if message := validate(acc.Data); message != "" {
+		writeError(writer, http.StatusBadRequest, "validation failure list:\n"+message)
+		return
+	}
+
+	data, err := s.accounts.Create(*acc.Data)
+	if err != nil {
+		writeError(writer, http.StatusConflict, "Account cannot be created as it violates a duplicate constraint")
+		return
+	}
+	writeAccount(writer, http.StatusCreated, data)
+}
+
+// GET /v1/organisation/accounts/{id}
+func (s *Server) fetch(writer http.ResponseWriter, id string) {
+	if !isUUID(id) {
+		writeError(writer, http.StatusBadRequest, "id is not a valid uuid")
+		return
+	}
+	data, ok := s.accounts.Get(id)
+	if !ok {
+		writeError(writer, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+		return
+	}
+	writeAccount(writer, http.StatusOK, data)
+}
+
+// PATCH /v1/organisation/accounts/{id}. the version in the body has to match the stored one, and only the attributes
+// present in the body are changed
+func (s *Server) patch(writer http.ResponseWriter, req *http.Request, id string) {
+	if !isUUID(id) {
+		writeError(writer, http.StatusBadRequest, "id is not a valid uuid")
+		return
+	}
+	var body struct {
+		Data *struct {
+			Attributes map[string]json.RawMessage `json:"attributes"`
+			Version    *int64                     `json:"version"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Data == nil || body.Data.Version == nil {
+		writeError(writer, http.StatusBadRequest, "invalid account data")
+		return
+	}
+
+	data, err := s.accounts.Patch(id, *body.Data.Version, body.Data.Attributes)
+	switch err {
+	case nil:
+		writeAccount(writer, http.StatusOK, data)
+	case memstore.ErrNotFound:
+		writeError(writer, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+	case memstore.ErrVersion:
+		writeError(writer, http.StatusConflict, "invalid version")
+	default:
+		writeError(writer, http.StatusBadRequest, "invalid account attributes")
+	}
+}
+
+// DELETE /v1/organisation/accounts/{id}?version={version}
+func (s *Server) delete(writer http.ResponseWriter, req *http.Request, id string) {
+	if !isUUID(id) {
+		writeError(writer, http.StatusBadRequest, "id is not a valid uuid")
+		return
+	}
+	version, err := strconv.ParseInt(req.URL.Query().Get("version"), 10, 64)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid version number")
+		return
+	}
+
+	switch s.accounts.Delete(id, version) {
+	case nil:
+		writer.WriteHeader(http.StatusNoContent)
+	case memstore.ErrNotFound:
+		writer.WriteHeader(http.StatusNotFound)
+	default:
+		writeError(writer, http.StatusConflict, "invalid version")
+	}
+}
+
+// GET /v1/organisation/accounts?page[number]=&page[size]=&filter[...]=
+func (s *Server) list(writer http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	number, size := 0, DefaultPageSize
+	if value := query.Get("page[number]"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			writeError(writer, http.StatusBadRequest, "invalid page number")
+			return
+		}
+		number = parsed
+	}
+	if value := query.Get("page[size]"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			writeError(writer, http.StatusBadRequest, "invalid page size")
+			return
+		}
+		size = parsed
+	}
+
+	matching := s.accounts.Find(query)
+	page := models.AccountList{
+		Data:  []models.AccountData{},
+		Links: memstore.Links(AccountsPath, query, number, size, len(matching)),
+	}
+	if data := memstore.Page(matching, number, size); data != nil {
+		page.Data = data
+	}
+	writeJSON(writer, http.StatusOK, page)
+}
+
+// the checks the real api runs on a new account, returning what failed or an empty string
+func validate(data *models.AccountData) string {
+	var failures []string
+	if !isUUID(data.ID) {
+		failures = append(failures, fmt.Sprintf("id in body must be of type uuid: %q", data.ID))
+	}
+	if !isUUID(data.OrganisationID) {
+		failures = append(failures, fmt.Sprintf("organisation_id in body must be of type uuid: %q", data.OrganisationID))
+	}
+	if data.Type != "accounts" {
+		failures = append(failures, fmt.Sprintf("type in body should be one of [accounts]: %q", data.Type))
+	}
+	if data.Attributes == nil {
+		failures = append(failures, "attributes in body is required")
+	} else {
+		if data.Attributes.Country == nil || *data.Attributes.Country == "" {
+			failures = append(failures, "country in body is required")
+		}
+		if len(data.Attributes.Name) == 0 {
+			failures = append(failures, "name in body is required")
+		}
+	}
+	return strings.Join(failures, "\n")
+}
+
+func isUUID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil && len(id) == 36
+}
+
+func writeAccount(writer http.ResponseWriter, status int, data models.AccountData) {
+	writeJSON(writer, status, models.Account{Data: &data, Links: &models.Links{Self: AccountsPath + "/" + data.ID}})
+}
+
+func writeError(writer http.ResponseWriter, status int, message string) {
+	writeJSON(writer, status, map[string]string{"error_message": message})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, body interface{}) {
+	writer.Header().Set("Content-Type", "application/vnd.api+json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(body)
+}
@@ -0,0 +1,199 @@
+package accountstest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatencyDistribution picks how long a request is held up for
+type LatencyDistribution func() time.Duration
+
+// every request is held up for exactly d
+func FixedLatency(d time.Duration) LatencyDistribution {
+	return func() time.Duration { return d }
+}
+
+// requests are held up for anything between min and max, evenly spread
+func UniformLatency(min time.Duration, max time.Duration) LatencyDistribution {
+	return func() time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(rand.Int63n(int64(max-min)))
+	}
+}
+
+// requests are held up for mean give or take stddev, normally distributed and never below zero. handy to get the
+// occasional slow request that trips a timeout
+func NormalLatency(mean time.Duration, stddev time.Duration) LatencyDistribution {
+	return func() time.Duration {
+		return time.Duration(math.Max(0, rand.NormFloat64()*float64(stddev)+float64(mean)))
+	}
+}
+
+// FaultRule scripts how the fake misbehaves. a rule matches requests by method and path, and fires on the matching
+// requests picked by Nth and Times. a request can match several rules; their latencies add up, and the first one with
+// a terminal fault (Status, DropConnection or MalformedJSON) decides the response. within that rule DropConnection
+// wins, then MalformedJSON, which is sent with the rule's Status and RetryAfter. requests no terminal fault fired for
+// are served as normal
+type FaultRule struct {
+	// only requests with this method match. empty matches any method
+	Method string
+	// only requests whose path starts with this match. empty matches any path
+	PathPrefix string
+	// fire on the nth matching request only, counting from 1. zero fires on every matching request
+	Nth int
+	// fire at most this many times. zero means no limit
+	Times int
+
+	// hold the request up before anything else happens. the wait ends early if the client gives up
+	Latency LatencyDistribution
+	// answer with this status and an error_message body instead of serving the request
+	Status int
+	// with Status or MalformedJSON, set a Retry-After header of this many seconds (rounded up). meant for 429s and 503s
+	RetryAfter time.Duration
+	// send the headers and part of the body, then close the connection
+	DropConnection bool
+	// answer with a body that isn't valid json, with Status (or a 200) and RetryAfter
+	MalformedJSON bool
+}
+
+// the nth request, counting from 1, gets status instead of being served
+func FailNth(n int, status int) FaultRule {
+	return FaultRule{Nth: n, Status: status}
+}
+
+// every request with method (empty for any) under pathPrefix gets status instead of being served
+func StatusFor(method string, pathPrefix string, status int) FaultRule {
+	return FaultRule{Method: method, PathPrefix: pathPrefix, Status: status}
+}
+
+// every request is held up by a latency from dist
+func WithLatency(dist LatencyDistribution) FaultRule {
+	return FaultRule{Latency: dist}
+}
+
+// every request has its connection dropped half way through the response body
+func DropConnection() FaultRule {
+	return FaultRule{DropConnection: true}
+}
+
+// every request gets a 200 with a truncated json body
+func MalformedJSON() FaultRule {
+	return FaultRule{MalformedJSON: true}
+}
+
+// every request gets a 429 with a Retry-After header
+func RateLimited(retryAfter time.Duration) FaultRule {
+	return FaultRule{Status: http.StatusTooManyRequests, RetryAfter: retryAfter}
+}
+
+// a rule as held by the server, with its counters
+type activeRule struct {
+	FaultRule
+	matched int
+	fired   int
+}
+
+// adds fault rules to the fake. they apply to every request from now on, until ClearFaults
+func (s *Server) AddFault(rules ...FaultRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rule := range rules {
+		s.faults = append(s.faults, &activeRule{FaultRule: rule})
+	}
+}
+
+// removes every fault rule, so the fake behaves again
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// the rules that fire for a request, in the order they were added. the lock must be held
+func (s *Server) firingRules(req *http.Request) []FaultRule {
+	var firing []FaultRule
+	for _, rule := range s.faults {
+		if (rule.Method != "" && rule.Method != req.Method) || !strings.HasPrefix(req.URL.Path, rule.PathPrefix) {
+			continue
+		}
+		rule.matched++
+		if (rule.Nth > 0 && rule.matched != rule.Nth) || (rule.Times > 0 && rule.fired >= rule.Times) {
+			continue
+		}
+		rule.fired++
+		firing = append(firing, rule.FaultRule)
+	}
+	return firing
+}
+
+// applies the rules that fire for a request. returns true if the api shouldn't serve it, because a rule answered it
+// or the client gave up while it was held up
+func injectFaults(writer http.ResponseWriter, req *http.Request, rules []FaultRule) bool {
+	for _, rule := range rules {
+		if rule.Latency == nil {
+			continue
+		}
+		timer := time.NewTimer(rule.Latency())
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return true
+		}
+	}
+
+	for _, rule := range rules {
+		switch {
+		case rule.DropConnection:
+			dropConnection(writer)
+			return true
+		case rule.MalformedJSON:
+			status := rule.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			setRetryAfter(writer, rule.RetryAfter)
+			writer.Header().Set("Content-Type", "application/vnd.api+json")
+			writer.WriteHeader(status)
+			fmt.Fprint(writer, `{"data": {"id": "`)
+			return true
+		case rule.Status != 0:
+			setRetryAfter(writer, rule.RetryAfter)
+			writeError(writer, rule.Status, "injected fault")
+			return true
+		}
+	}
+	return false
+}
+
+// sets a Retry-After header in whole seconds, rounded up, unless wait is zero
+func setRetryAfter(writer http.ResponseWriter, wait time.Duration) {
+	if wait > 0 {
+		writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	}
+}
+
+// promises a body, sends part of it, and hangs up. a writer that can't be hijacked, e.g. over http/2, gets a 500
+// instead, so the request still fails
+func dropConnection(writer http.ResponseWriter) {
+	hijacker, ok := writer.(http.Hijacker)
+	if !ok {
+		writeError(writer, http.StatusInternalServerError, "injected fault: the connection can't be dropped")
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	body := `{"data": {"id": "`
+	fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/vnd.api+json\r\nContent-Length: %d\r\n\r\n%s", len(body)*10, body)
+	buf.Flush()
+}
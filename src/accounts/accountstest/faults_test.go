@@ -0,0 +1,185 @@
+package accountstest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/accounts"
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// Faultstest-1 - failing the nth request should let a retrying client recover, and only the nth request is faulted
+func TestFaultFailNth(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	acc := newAccount("400300")
+	server.Seed(acc)
+	server.AddFault(FailNth(1, http.StatusServiceUnavailable))
+
+	c := client.NewDefaultClient()
+	c.SetRetryPolicy(&client.RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            time.Millisecond,
+		RetryableStatusCodes: map[int]bool{http.StatusServiceUnavailable: true},
+		RetryableMethods:     map[string]bool{http.MethodGet: true},
+	})
+	service := accounts.NewService(c, server.AccountsURL())
+
+	fetched, _, err := service.Fetch(acc.Data.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, acc.Data.ID, fetched.Data.ID)
+	requests := server.Requests()
+	assert.Len(t, requests, 2)
+	assert.True(t, requests[0].Faulted)
+	assert.False(t, requests[1].Faulted)
+
+	_, _, err = service.Fetch(acc.Data.ID)
+	assert.Nil(t, err, "the rule only fires once")
+}
+
+// Faultstest-2 - a status rule only applies to the method and path it was scoped to, and can be cleared
+func TestFaultStatusFor(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	server.AddFault(StatusFor(http.MethodPost, AccountsPath, http.StatusInternalServerError))
+
+	_, resp, err := service.Create(newAccount("400300"))
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	var apiErr *apierrors.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "injected fault", apiErr.ErrorMessage)
+	}
+	health, err := service.Health(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, health.Ready(), "other routes are left alone")
+
+	server.ClearFaults()
+	_, _, err = service.Create(newAccount("400300"))
+	assert.Nil(t, err)
+}
+
+// Faultstest-3 - a rate limited request should get a 429 with a Retry-After in whole seconds
+func TestFaultRateLimited(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	rule := RateLimited(1500 * time.Millisecond)
+	rule.Times = 1
+	server.AddFault(rule)
+
+	_, resp, err := service.Fetch(newAccount("400300").Data.ID)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Headers.Get("Retry-After"))
+	assert.NotNil(t, err)
+
+	_, resp, _ = service.Fetch(newAccount("400300").Data.ID)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// Faultstest-4 - latency should hold the request up, and trip the caller's timeout if it is long enough
+func TestFaultLatency(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	server.AddFault(WithLatency(UniformLatency(20*time.Millisecond, 30*time.Millisecond)))
+
+	start := time.Now()
+	_, err := service.Health(context.Background())
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(20*time.Millisecond))
+
+	server.ClearFaults()
+	server.AddFault(WithLatency(FixedLatency(time.Minute)))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, err = service.FetchWithCtx(ctx, newAccount("400300").Data.ID)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// Faultstest-5 - a dropped connection and a malformed body should both surface as errors rather than accounts
+func TestFaultBrokenResponses(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	acc := newAccount("400300")
+	server.Seed(acc)
+
+	server.AddFault(DropConnection())
+	_, _, err := service.Fetch(acc.Data.ID)
+	assert.NotNil(t, err)
+
+	server.ClearFaults()
+	server.AddFault(MalformedJSON())
+	_, _, err = service.Fetch(acc.Data.ID)
+	assert.NotNil(t, err)
+
+	assert.Len(t, server.Requests(), 2)
+	for _, req := range server.Requests() {
+		assert.True(t, req.Faulted)
+	}
+}
+
+// Faultstest-6 - NormalLatency never goes below zero
+func TestNormalLatency(t *testing.T) {
+	t.Parallel()
+	dist := NormalLatency(time.Millisecond, time.Second)
+	for i := 0; i < 100; i++ {
+		assert.GreaterOrEqual(t, int64(dist()), int64(0))
+	}
+	assert.Equal(t, 5*time.Millisecond, UniformLatency(5*time.Millisecond, time.Millisecond)())
+}
+
+// Faultstest-7 - a request the client gave up on while a latency rule held it up never reached the api, so it is
+// logged as faulted
+func TestFaultLatencyCancelIsFaulted(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	server.AddFault(WithLatency(FixedLatency(time.Minute)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := service.Health(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Eventually(t, func() bool {
+		requests := server.Requests()
+		return len(requests) == 1 && requests[0].Faulted
+	}, time.Second, 5*time.Millisecond)
+}
+
+// Faultstest-8 - a malformed body is sent with the rule's status and Retry-After
+func TestFaultMalformedJSONWithStatus(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	server.AddFault(FaultRule{MalformedJSON: true, Status: http.StatusServiceUnavailable, RetryAfter: time.Second})
+
+	_, resp, err := service.Fetch(newAccount("400300").Data.ID)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Headers.Get("Retry-After"))
+	assert.True(t, server.Requests()[0].Faulted)
+}
+
+// Faultstest-9 - a writer that can't be hijacked gets a 500 instead of a dropped connection
+func TestFaultDropConnectionWithoutHijacker(t *testing.T) {
+	t.Parallel()
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, HealthPath, nil)
+	assert.True(t, injectFaults(recorder, req, []FaultRule{DropConnection()}))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
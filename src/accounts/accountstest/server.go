@@ -0,0 +1,156 @@
+// Package accountstest provides an in-memory fake of the form3 accounts api for consumers' tests, in the spirit of
+// net/http/httptest. it needs no docker-compose:
+//
+//	server := accountstest.NewServer()
+//	defer server.Close()
+//	service := accounts.NewService(nil, server.AccountsURL())
+package accountstest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/accounts/internal/memstore"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+const (
+	// where the fake serves the accounts resource, same as the real api
+	AccountsPath = "/v1/organisation/accounts"
+	// where the fake serves its health check, same as the real api
+	HealthPath = "/v1/health"
+	// page size of the list endpoint when the request doesn't ask for one
+	DefaultPageSize = 100
+)
+
+// a request the fake received, as recorded in its request log
+type RecordedRequest struct {
+	Method   string
+	Path     string
+	RawQuery string
+	Header   http.Header
+	Body     []byte
+	Time     time.Time
+	// true if the api never served the request because of a fault rule, see AddFault. that is a rule answered it, or
+	// the client gave up while a rule was holding it up
+	Faulted bool
+
+	// tells the request apart in the log, so it can still be found once the fault rules are done with it
+	seq uint64
+}
+
+// Server is an in-memory fake of the accounts api that behaves like the real one: accounts are versioned, a duplicate
+// ID gets a 409, an unknown one a 404, a malformed UUID a 400, and the list endpoint pages with json:api links. the
+// accounts it holds and the requests it received can be inspected from the test
+type Server struct {
+	*httptest.Server
+
+	accounts memstore.Store
+
+	mu       sync.Mutex
+	requests []RecordedRequest
+	// the seq of the last request recorded
+	seq    uint64
+	faults []*activeRule
+}
+
+// starts a fake accounts api. the caller should Close it when done
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// the url of the accounts resource, to hand to accounts.NewService
+func (s *Server) AccountsURL() string {
+	return s.URL + AccountsPath
+}
+
+// adds accounts straight to the fake's state, without going through the api. the server populated fields are
+// filled in like a create would, unless they are already set
+func (s *Server) Seed(accs ...models.Account) {
+	for _, acc := range accs {
+		if acc.Data != nil {
+			s.accounts.Seed(*acc.Data)
+		}
+	}
+}
+
+// the account the fake holds under id, if any
+func (s *Server) Account(id string) (models.AccountData, bool) {
+	return s.accounts.Get(id)
+}
+
+// every account the fake holds, in the order they were created
+func (s *Server) Accounts() []models.AccountData {
+	return s.accounts.All()
+}
+
+// every request the fake received so far, oldest first
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+// the requests the fake received with the given method, oldest first
+func (s *Server) RequestsWithMethod(method string) []RecordedRequest {
+	var matching []RecordedRequest
+	for _, req := range s.Requests() {
+		if req.Method == method {
+			matching = append(matching, req)
+		}
+	}
+	return matching
+}
+
+// forgets every account, every recorded request and every fault rule
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts.Reset()
+	s.requests = nil
+	s.faults = nil
+}
+
+// records the request, then hands it to the fault rules and, unless one of them answered it, to the api
+func (s *Server) serveHTTP(writer http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	s.mu.Lock()
+	rules := s.firingRules(req)
+	s.seq++
+	seq := s.seq
+	s.requests = append(s.requests, RecordedRequest{
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		RawQuery: req.URL.RawQuery,
+		Header:   req.Header.Clone(),
+		Body:     body,
+		Time:     time.Now(),
+		seq:      seq,
+	})
+	s.mu.Unlock()
+
+	if injectFaults(writer, req, rules) {
+		s.markFaulted(seq)
+		return
+	}
+	s.serveAPI(writer, req)
+}
+
+// marks a recorded request as faulted. it is gone already if the log was Reset in the meantime
+func (s *Server) markFaulted(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		if s.requests[i].seq == seq {
+			s.requests[i].Faulted = true
+			return
+		}
+	}
+}
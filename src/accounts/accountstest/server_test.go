@@ -0,0 +1,173 @@
+package accountstest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sarabrajsingh/interview-accountapi/src/accounts"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that returns an account the fake accepts
+func newAccount(bankID string) models.Account {
+	country := "GB"
+	return models.Account{Data: &models.AccountData{
+		ID:             uuid.New().String(),
+		OrganisationID: uuid.New().String(),
+		Type:           "accounts",
+		Attributes: &models.AccountAttributes{
+			BankID:  bankID,
+			Country: &country,
+			Name:    []string{"Samantha Holder"},
+		},
+	}}
+}
+
+// Fakeservertest-1 - create, fetch, update and delete should behave like the real api, versions included
+func TestFakeServerLifecycle(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	acc := newAccount("400300")
+	id := acc.Data.ID
+
+	created, resp, err := service.Create(acc)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int64(0), *created.Data.Version)
+	assert.NotNil(t, created.Data.CreatedOn)
+	assert.Equal(t, AccountsPath+"/"+id, created.Links.Self)
+
+	_, resp, err = service.Create(acc)
+	assert.True(t, errors.Is(err, apierrors.ErrConflict))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	fetched, _, err := service.Fetch(id)
+	assert.Nil(t, err)
+	assert.Equal(t, "400300", fetched.Data.Attributes.BankID)
+
+	updated, _, err := service.Update(id, 0, models.AccountAttributes{BankID: "400302"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), *updated.Data.Version)
+	assert.Equal(t, "400302", updated.Data.Attributes.BankID)
+	assert.Equal(t, []string{"Samantha Holder"}, updated.Data.Attributes.Name, "attributes left out of the patch are kept")
+
+	_, _, err = service.Update(id, 0, models.AccountAttributes{BankID: "400303"})
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict))
+	assert.True(t, errors.Is(service.Delete(id, 0), apierrors.ErrVersionConflict))
+
+	assert.Nil(t, service.Delete(id, 1))
+	_, _, err = service.Fetch(id)
+	assert.True(t, errors.Is(err, apierrors.ErrNotFound))
+	assert.True(t, errors.Is(service.Delete(id, 1), apierrors.ErrNotFound))
+	assert.Empty(t, server.Accounts())
+}
+
+// Fakeservertest-2 - malformed ids and invalid accounts should get a 400
+func TestFakeServerValidation(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+
+	_, resp, err := service.Fetch("superfake.com")
+	assert.True(t, errors.Is(err, apierrors.ErrBadRequest))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	acc := newAccount("400300")
+	acc.Data.ID = "abc123"
+	_, _, err = service.Create(acc)
+	var apiErr *apierrors.APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, apierrors.ErrBadRequest, apiErr.Code)
+		assert.Contains(t, apiErr.ErrorMessage, "id in body must be of type uuid")
+	}
+
+	acc = newAccount("400300")
+	acc.Data.Attributes.Country = nil
+	_, _, err = service.Create(acc)
+	assert.True(t, errors.Is(err, apierrors.ErrBadRequest))
+	assert.Empty(t, server.Accounts())
+}
+
+// Fakeservertest-3 - the list endpoint should page through the accounts in creation order, and filter them
+func TestFakeServerList(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	var ids []string
+	for i := 0; i < 7; i++ {
+		bankID := "400300"
+		if i%2 == 1 {
+			bankID = "400302"
+		}
+		acc := newAccount(bankID)
+		ids = append(ids, acc.Data.ID)
+		server.Seed(acc)
+	}
+
+	page, _, err := service.ListPage(accounts.ListOptions{PageSize: 3})
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 3)
+	assert.Equal(t, AccountsPath+"?page%5Bnumber%5D=1&page%5Bsize%5D=3", page.Links.Next)
+	assert.Equal(t, AccountsPath+"?page%5Bnumber%5D=2&page%5Bsize%5D=3", page.Links.Last)
+	assert.Empty(t, page.Links.Prev)
+
+	var listed []string
+	it := service.List(accounts.ListOptions{PageSize: 3})
+	for it.Next() {
+		listed = append(listed, it.Account().Data.ID)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, ids, listed)
+
+	found, err := service.Find(accounts.AccountFilter{BankID: []string{"400302"}})
+	assert.Nil(t, err)
+	assert.Len(t, found, 3)
+	for _, acc := range found {
+		assert.Equal(t, "400302", acc.Data.Attributes.BankID)
+	}
+}
+
+// Fakeservertest-4 - every request should be in the log, and the state should be inspectable and resettable
+func TestFakeServerInspection(t *testing.T) {
+	t.Parallel()
+	server := NewServer()
+	defer server.Close()
+	service := accounts.NewService(nil, server.AccountsURL())
+	acc := newAccount("400300")
+
+	_, _, err := service.Create(acc)
+	assert.Nil(t, err)
+	service.Fetch(acc.Data.ID)
+	health, err := service.Health(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, health.Ready())
+
+	requests := server.Requests()
+	assert.Len(t, requests, 3)
+	assert.Equal(t, http.MethodPost, requests[0].Method)
+	assert.Equal(t, AccountsPath, requests[0].Path)
+	assert.NotEmpty(t, requests[0].Header.Get("Idempotency-Key"))
+	assert.Contains(t, string(requests[0].Body), acc.Data.ID)
+	assert.Len(t, server.RequestsWithMethod(http.MethodGet), 2)
+
+	stored, ok := server.Account(acc.Data.ID)
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now(), *stored.CreatedOn, time.Minute)
+	stored.Attributes.BankID = "changed"
+	again, _ := server.Account(acc.Data.ID)
+	assert.Equal(t, "400300", again.Attributes.BankID, "the test shouldn't be able to change the fake's state by accident")
+
+	server.Reset()
+	assert.Empty(t, server.Accounts())
+	assert.Empty(t, server.Requests())
+}
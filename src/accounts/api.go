@@ -4,104 +4,211 @@ package accounts
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
 	"os"
-	"strconv"
+	"sync"
+	"time"
 
 	"github.com/sarabrajsingh/interview-accountapi/src/client"
 	"github.com/sarabrajsingh/interview-accountapi/src/models"
 )
 
+const defaultAccountsURL = "http://localhost:8080/v1/organisation/accounts"
+
+// the base URL of an accounts api. it is read and written through GetDefaultBaseURL() and SetBaseURL(), which makes
+// it safe to repoint while requests are in flight
 type URL struct {
-	BaseURL string
+	baseURL string
+	mu      sync.RWMutex
 }
 
-var DefaultUrl URL
+// the environment is read once, when the package is loaded. use SetBaseURL() to point somewhere else afterwards
+var DefaultUrl = URL{baseURL: baseURLFromEnv()}
 
-func (u *URL) defaultBaseURL() {
+func baseURLFromEnv() string {
 	url := os.Getenv("FORM3_ACCOUNTS_API_URL")
 	if url == "" {
-		url = "http://localhost:8080/v1/organisation/accounts"
+		url = defaultAccountsURL
 	}
-	u.BaseURL = url
+	return url
 }
 
+// returns the base URL, falling back to the environment (without storing it) if none has been set
 func (u *URL) GetDefaultBaseURL() string {
-	u.defaultBaseURL()
-	return u.BaseURL
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if u.baseURL == "" {
+		return baseURLFromEnv()
+	}
+	return u.baseURL
 }
 
 // helper function for the URL struct used in this module
 func (u *URL) SetBaseURL(url string) {
-	u.BaseURL = url
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.baseURL = url
 }
 
-// CREATE account without custom user context
-func Create(acc models.Account) (*client.Response, error) {
-	accEncoded, err := json.Marshal(acc)
+// the package level functions below are thin wrappers around DefaultService
 
-	if err != nil {
-		return nil, err
-	}
+// checks the health of the accounts api
+func Health(ctx context.Context) (*client.HealthCheck, error) {
+	return DefaultService.Health(ctx)
+}
+
+// blocks until the accounts api reports healthy, or ctx is done
+func WaitUntilReady(ctx context.Context, pollInterval time.Duration) error {
+	return DefaultService.WaitUntilReady(ctx, pollInterval)
+}
 
-	return client.Send(client.Request{
-		Method:  http.MethodPost,
-		BaseURL: DefaultUrl.GetDefaultBaseURL(),
-		Body:    accEncoded,
-	})
+// CREATE account without custom user context
+func Create(acc models.Account) (*models.Account, *client.Response, error) {
+	return DefaultService.Create(acc)
 }
 
 // there is no method overloading in golang, nor are there default params like in python, so we need to create
 // concrete methods that encompass all functionalities
 // Create with custom context
-func CreateWithCtx(ctx context.Context, acc models.Account) (*client.Response, error) {
-	accEncoded, err := json.Marshal(acc)
-	if err != nil {
-		return nil, err
-	}
-	return client.SendWithCtx(ctx, client.Request{
-		Method:  http.MethodPost,
-		BaseURL: DefaultUrl.GetDefaultBaseURL(),
-		Body:    accEncoded,
-	})
+func CreateWithCtx(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error) {
+	return DefaultService.CreateWithCtx(ctx, acc)
+}
+
+// resolves an ambiguous create, see Service.ResolveCreateWithCtx
+func ResolveCreate(acc models.Account) (*CreateResolution, error) {
+	return DefaultService.ResolveCreate(acc)
+}
+
+// resolve create with context implementation
+func ResolveCreateWithCtx(ctx context.Context, acc models.Account) (*CreateResolution, error) {
+	return DefaultService.ResolveCreateWithCtx(ctx, acc)
+}
+
+// creates an account, or gets it if the ID is already taken, see Service.CreateOrGetWithCtx
+func CreateOrGet(acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error) {
+	return DefaultService.CreateOrGet(acc, opts)
+}
+
+// create or get with context implementation
+func CreateOrGetWithCtx(ctx context.Context, acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error) {
+	return DefaultService.CreateOrGetWithCtx(ctx, acc, opts)
 }
 
 // fetch implementation
-func Fetch(id string) (*client.Response, error) {
-	return client.Send(client.Request{
-		Method:  http.MethodGet,
-		BaseURL: fmt.Sprintf("%s/%s", DefaultUrl.GetDefaultBaseURL(), id),
-	})
+func Fetch(id string) (*models.Account, *client.Response, error) {
+	return DefaultService.Fetch(id)
 }
 
 // fetch with context implementation
-func FetchWithCtx(ctx context.Context, id string) (*client.Response, error) {
-	return client.SendWithCtx(ctx, client.Request{
-		Method:  http.MethodGet,
-		BaseURL: fmt.Sprintf("%s/%s", DefaultUrl.GetDefaultBaseURL(), id),
-	})
+func FetchWithCtx(ctx context.Context, id string) (*models.Account, *client.Response, error) {
+	return DefaultService.FetchWithCtx(ctx, id)
 }
 
 // delete implementation
-func Delete(id string, version int) (*client.Response, error) {
-	return client.Send(client.Request{
-		Method:  http.MethodDelete,
-		BaseURL: fmt.Sprintf("%s/%s", DefaultUrl.GetDefaultBaseURL(), id),
-		QueryParams: map[string]string{
-			"version": strconv.Itoa(version),
-		},
-	})
+func Delete(id string, version int) error {
+	return DefaultService.Delete(id, version)
 }
 
 // delete with context implementation
-func DeleteWithCtx(ctx context.Context, id string, version int) (*client.Response, error) {
-	return client.SendWithCtx(ctx, client.Request{
-		Method:  http.MethodDelete,
-		BaseURL: fmt.Sprintf("%s/%s", DefaultUrl.GetDefaultBaseURL(), id),
-		QueryParams: map[string]string{
-			"version": strconv.Itoa(version),
-		},
-	})
+func DeleteWithCtx(ctx context.Context, id string, version int) error {
+	return DefaultService.DeleteWithCtx(ctx, id, version)
+}
+
+// deletes whatever version of an account is current, see Service.DeleteLatestWithCtx
+func DeleteLatest(id string, opts DeleteOptions) error {
+	return DefaultService.DeleteLatest(id, opts)
+}
+
+// delete latest with context implementation
+func DeleteLatestWithCtx(ctx context.Context, id string, opts DeleteOptions) error {
+	return DefaultService.DeleteLatestWithCtx(ctx, id, opts)
+}
+
+// update implementation
+func Update(id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error) {
+	return DefaultService.Update(id, version, patch)
+}
+
+// update with context implementation
+func UpdateWithCtx(ctx context.Context, id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error) {
+	return DefaultService.UpdateWithCtx(ctx, id, version, patch)
+}
+
+// list implementation, returning an iterator over every account
+func List(opts ListOptions) *AccountIterator {
+	return DefaultService.List(opts)
+}
+
+// list with context implementation
+func ListWithCtx(ctx context.Context, opts ListOptions) *AccountIterator {
+	return DefaultService.ListWithCtx(ctx, opts)
+}
+
+// fetches a single page of accounts
+func ListPage(opts ListOptions) (*models.AccountList, *client.Response, error) {
+	return DefaultService.ListPage(opts)
+}
+
+// fetches a single page of accounts with context
+func ListPageWithCtx(ctx context.Context, opts ListOptions) (*models.AccountList, *client.Response, error) {
+	return DefaultService.ListPageWithCtx(ctx, opts)
+}
+
+// returns every account matching the filter
+func Find(filter AccountFilter) ([]models.Account, error) {
+	return DefaultService.Find(filter)
+}
+
+// find with context implementation
+func FindWithCtx(ctx context.Context, filter AccountFilter) ([]models.Account, error) {
+	return DefaultService.FindWithCtx(ctx, filter)
+}
+
+// returns the accounts with the given iban
+func FindByIBAN(iban string) ([]models.Account, error) {
+	return DefaultService.FindByIBAN(iban)
+}
+
+// find by iban with context implementation
+func FindByIBANWithCtx(ctx context.Context, iban string) ([]models.Account, error) {
+	return DefaultService.FindByIBANWithCtx(ctx, iban)
+}
+
+// returns the accounts with the given account number
+func FindByAccountNumber(accountNumber string) ([]models.Account, error) {
+	return DefaultService.FindByAccountNumber(accountNumber)
+}
+
+// find by account number with context implementation
+func FindByAccountNumberWithCtx(ctx context.Context, accountNumber string) ([]models.Account, error) {
+	return DefaultService.FindByAccountNumberWithCtx(ctx, accountNumber)
+}
+
+// creates every account in accs, see Service.BulkCreate
+func BulkCreate(ctx context.Context, accs []models.Account, opts BulkOptions) *BulkReport {
+	return DefaultService.BulkCreate(ctx, accs, opts)
+}
+
+// creates every account received on accs, see Service.BulkCreateFrom
+func BulkCreateFrom(ctx context.Context, accs <-chan models.Account, opts BulkOptions) *BulkReport {
+	return DefaultService.BulkCreateFrom(ctx, accs, opts)
+}
+
+// fetches every account in ids, see Service.BulkFetch
+func BulkFetch(ctx context.Context, ids []string, opts BulkOptions) *BulkReport {
+	return DefaultService.BulkFetch(ctx, ids, opts)
+}
+
+// fetches every account received on ids, see Service.BulkFetchFrom
+func BulkFetchFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport {
+	return DefaultService.BulkFetchFrom(ctx, ids, opts)
+}
+
+// deletes every account in ids, see Service.BulkDelete
+func BulkDelete(ctx context.Context, ids []string, opts BulkOptions) *BulkReport {
+	return DefaultService.BulkDelete(ctx, ids, opts)
+}
+
+// deletes every account received on ids, see Service.BulkDeleteFrom
+func BulkDeleteFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport {
+	return DefaultService.BulkDeleteFrom(ctx, ids, opts)
 }
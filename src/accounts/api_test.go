@@ -1,6 +1,8 @@
 package accounts
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,5 +11,34 @@ import (
 // Unittest-1 - Make sure the SetBaseURL() function on our custom struct URL type, works as expected.
 func TestSetBaseURL(t *testing.T) {
 	DefaultUrl.SetBaseURL("super.fake.com")
-	assert.Equal(t, DefaultUrl.BaseURL, "super.fake.com", "setting custom BaseURL failed")
+	assert.Equal(t, DefaultUrl.GetDefaultBaseURL(), "super.fake.com", "setting custom BaseURL failed")
+}
+
+// Unittest-2 - an empty URL falls back to the environment, without storing the result
+func TestGetDefaultBaseURLFallback(t *testing.T) {
+	t.Setenv("FORM3_ACCOUNTS_API_URL", "http://from.the.env/v1/organisation/accounts")
+	u := &URL{}
+	assert.Equal(t, "http://from.the.env/v1/organisation/accounts", u.GetDefaultBaseURL())
+	assert.Equal(t, "", u.baseURL, "GetDefaultBaseURL should not mutate the URL")
+
+	t.Setenv("FORM3_ACCOUNTS_API_URL", "")
+	assert.Equal(t, defaultAccountsURL, u.GetDefaultBaseURL())
+}
+
+// Unittest-3 - the package level functions should go through DefaultService and DefaultUrl
+func TestPackageFunctionsUseDefaultService(t *testing.T) {
+	paths := make(chan string, 1)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		paths <- req.URL.Path
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+
+	original := DefaultUrl.GetDefaultBaseURL()
+	defer DefaultUrl.SetBaseURL(original)
+	DefaultUrl.SetBaseURL(mockServer.URL + "/v1/organisation/accounts")
+
+	err := Delete("f773707e-769e-4ed6-9194-ab69ff639d39", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/organisation/accounts/f773707e-769e-4ed6-9194-ab69ff639d39", <-paths)
 }
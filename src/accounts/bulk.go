@@ -0,0 +1,260 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// the error on items a bulk operation never started, because an earlier item failed with BulkOptions.FailFast set
+var ErrSkipped = errors.New("skipped after an earlier failure")
+
+// options for the bulk operations
+type BulkOptions struct {
+	// how many requests are in flight at once. defaults to 4
+	Concurrency int
+	// stop starting new items after the first failure. items already in flight are left to finish, and the rest come
+	// back with ErrSkipped
+	FailFast bool
+	// called after every item, with its result and the stats so far. calls are serialised, so it doesn't need a lock
+	// of its own, but it holds up the other items while it runs
+	OnProgress func(result BulkResult, stats BulkStats)
+	// how BulkDelete deletes each account
+	Delete DeleteOptions
+}
+
+// the outcome of a single item of a bulk operation
+type BulkResult struct {
+	// position of the item in the input
+	Index int
+	ID    string
+	// the created or fetched account. always nil for deletes
+	Account *models.Account
+	// status code of the last response for the item, 0 if there was none
+	StatusCode int
+	Err        error
+	// true if the item was never sent, because of FailFast or because the context was done
+	Skipped bool
+}
+
+// aggregate stats of a bulk operation
+type BulkStats struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Duration  time.Duration
+}
+
+// the outcome of a bulk operation, with one result per item in input order
+type BulkReport struct {
+	Results []BulkResult
+	Stats   BulkStats
+}
+
+// the error of the first item that failed, in input order, or nil if every item succeeded. skipped items don't count
+func (r *BulkReport) FirstErr() error {
+	for _, result := range r.Results {
+		if result.Err != nil && !result.Skipped {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// a single item of the input; an account to create, or the id of an account to fetch or delete
+type bulkItem struct {
+	id  string
+	acc models.Account
+}
+
+// creates every account in accs, at most Concurrency at a time
+func (s *Service) BulkCreate(ctx context.Context, accs []models.Account, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, accountItems(accs), opts, s.bulkCreate)
+}
+
+// creates every account received on accs until it is closed, at most Concurrency at a time
+func (s *Service) BulkCreateFrom(ctx context.Context, accs <-chan models.Account, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, accountItemsFrom(accs), opts, s.bulkCreate)
+}
+
+// fetches every account in ids, at most Concurrency at a time
+func (s *Service) BulkFetch(ctx context.Context, ids []string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItems(ids), opts, s.bulkFetch)
+}
+
+// fetches every account received on ids until it is closed, at most Concurrency at a time
+func (s *Service) BulkFetchFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItemsFrom(ids), opts, s.bulkFetch)
+}
+
+// deletes the latest version of every account in ids, at most Concurrency at a time. see DeleteLatest for how
+// BulkOptions.Delete is applied to each of them
+func (s *Service) BulkDelete(ctx context.Context, ids []string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItems(ids), opts, s.bulkDelete)
+}
+
+// deletes the latest version of every account received on ids until it is closed, at most Concurrency at a time
+func (s *Service) BulkDeleteFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItemsFrom(ids), opts, s.bulkDelete)
+}
+
+func (s *Service) bulkCreate(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+	created, resp, err := s.CreateWithCtx(ctx, item.acc)
+	return BulkResult{ID: item.id, Account: created, StatusCode: statusCode(resp), Err: err}
+}
+
+func (s *Service) bulkFetch(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+	fetched, resp, err := s.FetchWithCtx(ctx, item.id)
+	return BulkResult{ID: item.id, Account: fetched, StatusCode: statusCode(resp), Err: err}
+}
+
+func (s *Service) bulkDelete(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+	resp, err := s.deleteLatest(ctx, item.id, opts.Delete)
+	return BulkResult{ID: item.id, StatusCode: statusCode(resp), Err: err}
+}
+
+// the engine behind every bulk operation. items are read in order and handed to do on up to Concurrency goroutines.
+// items is always read until it is closed, even once nothing more is being sent, so its producer is never left blocked
+func runBulk(ctx context.Context, items <-chan bulkItem, opts BulkOptions,
+	do func(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult) *BulkReport {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	start := time.Now()
+	report := &BulkReport{}
+
+	var mu sync.Mutex
+	failed := false
+	record := func(index int, result BulkResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		result.Index = index
+		for len(report.Results) <= index {
+			report.Results = append(report.Results, BulkResult{})
+		}
+		report.Results[index] = result
+		report.Stats.Total++
+		switch {
+		case result.Skipped:
+			report.Stats.Skipped++
+		case result.Err != nil:
+			report.Stats.Failed++
+			failed = true
+		default:
+			report.Stats.Succeeded++
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(result, report.Stats)
+		}
+	}
+	// why a new item shouldn't be sent, if there is a reason
+	stopped := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failed && opts.FailFast {
+			return ErrSkipped
+		}
+		return ctx.Err()
+	}
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	index := 0
+	for item := range items {
+		i := index
+		index++
+		if err := stopped(); err != nil {
+			record(i, BulkResult{ID: item.id, Err: err, Skipped: true})
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			record(i, BulkResult{ID: item.id, Err: ctx.Err(), Skipped: true})
+			continue
+		}
+		// a failure may have come in while we were waiting for a slot
+		if err := stopped(); err != nil {
+			<-slots
+			record(i, BulkResult{ID: item.id, Err: err, Skipped: true})
+			continue
+		}
+
+		wg.Add(1)
+		go func(item bulkItem) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			record(i, do(ctx, item, opts))
+		}(item)
+	}
+	wg.Wait()
+
+	report.Stats.Duration = time.Since(start)
+	return report
+}
+
+// helpers that turn the input of a bulk operation into a stream of items
+func accountItem(acc models.Account) bulkItem {
+	item := bulkItem{acc: acc}
+	if acc.Data != nil {
+		item.id = acc.Data.ID
+	}
+	return item
+}
+
+func accountItems(accs []models.Account) <-chan bulkItem {
+	items := make(chan bulkItem)
+	go func() {
+		defer close(items)
+		for _, acc := range accs {
+			items <- accountItem(acc)
+		}
+	}()
+	return items
+}
+
+func accountItemsFrom(accs <-chan models.Account) <-chan bulkItem {
+	items := make(chan bulkItem)
+	go func() {
+		defer close(items)
+		for acc := range accs {
+			items <- accountItem(acc)
+		}
+	}()
+	return items
+}
+
+func idItems(ids []string) <-chan bulkItem {
+	items := make(chan bulkItem)
+	go func() {
+		defer close(items)
+		for _, id := range ids {
+			items <- bulkItem{id: id}
+		}
+	}()
+	return items
+}
+
+func idItemsFrom(ids <-chan string) <-chan bulkItem {
+	items := make(chan bulkItem)
+	go func() {
+		defer close(items)
+		for id := range ids {
+			items <- bulkItem{id: id}
+		}
+	}()
+	return items
+}
+
+func statusCode(resp *client.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
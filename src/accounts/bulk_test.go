@@ -0,0 +1,175 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that spins up a slow-ish backend which knows every account except the ones with a "missing" id.
+// it records the highest number of requests it saw in flight at once
+func newBulkServer(maxInFlight *int32) *httptest.Server {
+	var inFlight int32
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		id := strings.TrimPrefix(req.URL.Path, "/")
+		switch {
+		case req.Method == http.MethodPost:
+			writer.WriteHeader(http.StatusCreated)
+			fmt.Fprint(writer, `{"data": {"version": 0}}`)
+		case strings.HasPrefix(id, "missing"):
+			writer.WriteHeader(http.StatusNotFound)
+		case req.Method == http.MethodDelete:
+			writer.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprintf(writer, `{"data": {"id": %q, "version": 0}}`, id)
+		}
+	}))
+}
+
+// Bulktest-1 - results should come back in input order, with per-item status and error, and never more requests in
+// flight than the concurrency limit
+func TestBulkFetch(t *testing.T) {
+	t.Parallel()
+	var maxInFlight int32
+	mockServer := newBulkServer(&maxInFlight)
+	defer mockServer.Close()
+
+	ids := []string{"a", "b", "missing-c", "d", "e", "f", "missing-g", "h"}
+	report := NewService(nil, mockServer.URL).BulkFetch(context.Background(), ids, BulkOptions{Concurrency: 3})
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(3))
+	assert.Len(t, report.Results, len(ids))
+	for i, result := range report.Results {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, ids[i], result.ID)
+		if strings.HasPrefix(ids[i], "missing") {
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+			assert.True(t, errors.Is(result.Err, apierrors.ErrNotFound))
+			assert.Nil(t, result.Account)
+		} else {
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.Nil(t, result.Err)
+			assert.Equal(t, ids[i], result.Account.Data.ID)
+		}
+	}
+	assert.Equal(t, 8, report.Stats.Total)
+	assert.Equal(t, 6, report.Stats.Succeeded)
+	assert.Equal(t, 2, report.Stats.Failed)
+	assert.Equal(t, 0, report.Stats.Skipped)
+	assert.True(t, errors.Is(report.FirstErr(), apierrors.ErrNotFound))
+}
+
+// Bulktest-2 - with fail-fast, nothing new is sent after the first failure
+func TestBulkFailFast(t *testing.T) {
+	t.Parallel()
+	var maxInFlight int32
+	mockServer := newBulkServer(&maxInFlight)
+	defer mockServer.Close()
+
+	ids := []string{"a", "missing-b", "c", "d"}
+	report := NewService(nil, mockServer.URL).BulkFetch(context.Background(), ids, BulkOptions{Concurrency: 1, FailFast: true})
+
+	assert.Nil(t, report.Results[0].Err)
+	assert.True(t, errors.Is(report.Results[1].Err, apierrors.ErrNotFound))
+	for _, result := range report.Results[2:] {
+		assert.True(t, result.Skipped)
+		assert.Equal(t, ErrSkipped, result.Err)
+		assert.Equal(t, 0, result.StatusCode)
+	}
+	assert.Equal(t, BulkStats{Total: 4, Succeeded: 1, Failed: 1, Skipped: 2, Duration: report.Stats.Duration}, report.Stats)
+	assert.True(t, errors.Is(report.FirstErr(), apierrors.ErrNotFound))
+}
+
+// Bulktest-3 - a channel is read until it is closed, and progress is reported after every item
+func TestBulkCreateFromChannel(t *testing.T) {
+	t.Parallel()
+	var maxInFlight int32
+	mockServer := newBulkServer(&maxInFlight)
+	defer mockServer.Close()
+
+	accs := make(chan models.Account)
+	go func() {
+		defer close(accs)
+		for i := 0; i < 10; i++ {
+			accs <- models.Account{Data: &models.AccountData{ID: fmt.Sprintf("account-%d", i)}}
+		}
+	}()
+	var progress []int
+	report := NewService(nil, mockServer.URL).BulkCreateFrom(context.Background(), accs, BulkOptions{
+		OnProgress: func(result BulkResult, stats BulkStats) {
+			progress = append(progress, stats.Succeeded)
+		},
+	})
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, progress)
+	assert.Equal(t, 10, report.Stats.Succeeded)
+	for i, result := range report.Results {
+		assert.Equal(t, fmt.Sprintf("account-%d", i), result.ID)
+		assert.Equal(t, http.StatusCreated, result.StatusCode)
+	}
+	assert.Nil(t, report.FirstErr())
+}
+
+// Bulktest-4 - bulk delete deletes the latest version, applying the delete options to each account
+func TestBulkDelete(t *testing.T) {
+	t.Parallel()
+	var maxInFlight int32
+	mockServer := newBulkServer(&maxInFlight)
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+	ids := []string{"a", "missing-b"}
+
+	report := service.BulkDelete(context.Background(), ids, BulkOptions{})
+	assert.Equal(t, http.StatusNoContent, report.Results[0].StatusCode)
+	assert.Nil(t, report.Results[0].Err)
+	assert.True(t, errors.Is(report.Results[1].Err, apierrors.ErrNotFound))
+
+	report = service.BulkDelete(context.Background(), ids, BulkOptions{Delete: DeleteOptions{IgnoreNotFound: true}})
+	assert.Equal(t, 2, report.Stats.Succeeded)
+}
+
+// Bulktest-5 - once the context is done, the remaining items are skipped rather than sent
+func TestBulkStopsOnContextCancel(t *testing.T) {
+	t.Parallel()
+	var maxInFlight int32
+	mockServer := newBulkServer(&maxInFlight)
+	defer mockServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	report := NewService(nil, mockServer.URL).BulkFetch(ctx, []string{"a", "b", "c", "d"}, BulkOptions{
+		Concurrency: 1,
+		OnProgress: func(result BulkResult, stats BulkStats) {
+			cancel()
+		},
+	})
+
+	assert.Nil(t, report.Results[0].Err)
+	assert.Equal(t, 1, report.Stats.Succeeded)
+	assert.Equal(t, 3, report.Stats.Skipped)
+	for _, result := range report.Results[1:] {
+		assert.True(t, result.Skipped)
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+	assert.Nil(t, report.FirstErr())
+}
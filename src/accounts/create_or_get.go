@@ -0,0 +1,62 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// options for CreateOrGet
+type CreateOrGetOptions struct {
+	// compare the existing account with the requested one when the ID was already taken, see Diff()
+	Diff bool
+}
+
+// the outcome of CreateOrGet
+type CreateOrGetResult struct {
+	// the account the backend holds, whether it was just created or already there
+	Account *models.Account
+	// true if this call created the account
+	Created bool
+	// how the existing account differs from the requested one. only filled in with CreateOrGetOptions.Diff, when the
+	// account already existed
+	Diff []FieldDiff
+}
+
+// create or get without custom user context. see CreateOrGetWithCtx
+func (s *Service) CreateOrGet(acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error) {
+	return s.CreateOrGetWithCtx(context.Background(), acc, opts)
+}
+
+// creates an account, or, when the backend answers 409 because the ID is already taken, fetches the existing account
+// instead. Created tells the two apart. any other failure, including the existing account going away before it
+// could be fetched, comes back as an error
+func (s *Service) CreateOrGetWithCtx(ctx context.Context, acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error) {
+	return createOrGet(ctx, acc, opts, s.CreateWithCtx, s.FetchWithCtx)
+}
+
+// the create then fetch behind CreateOrGet, on top of any create and fetch. the fake runs it too, so both behave the
+// same
+func createOrGet(ctx context.Context, acc models.Account, opts CreateOrGetOptions, create createFunc,
+	fetch fetchFunc) (*CreateOrGetResult, error) {
+	created, _, err := create(ctx, acc)
+	if err == nil {
+		return &CreateOrGetResult{Account: created, Created: true}, nil
+	}
+	if !errors.Is(err, apierrors.ErrConflict) || acc.Data == nil || acc.Data.ID == "" {
+		return nil, err
+	}
+
+	existing, _, err := fetch(ctx, acc.Data.ID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching existing account %s after a conflict on create: %w", acc.Data.ID, err)
+	}
+	result := &CreateOrGetResult{Account: existing}
+	if opts.Diff {
+		result.Diff = Diff(acc, *existing)
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that spins up a backend answering creates with createStatus, and fetches with fetchStatus
+func newCreateOrGetServer(createStatus int, fetchStatus int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		status := fetchStatus
+		if req.Method == http.MethodPost {
+			status = createStatus
+		}
+		writer.WriteHeader(status)
+		if status < 300 {
+			fmt.Fprint(writer, testAccountResponse)
+		}
+	}))
+}
+
+// CreateOrGettest-1 - a successful create is reported as created
+func TestCreateOrGetCreates(t *testing.T) {
+	t.Parallel()
+	mockServer := newCreateOrGetServer(http.StatusCreated, http.StatusNotFound)
+	defer mockServer.Close()
+
+	result, err := NewService(nil, mockServer.URL).CreateOrGet(testSubmittedAccount(), CreateOrGetOptions{Diff: true})
+	assert.Nil(t, err)
+	assert.True(t, result.Created)
+	assert.Equal(t, testAccountID, result.Account.Data.ID)
+	assert.Empty(t, result.Diff)
+}
+
+// CreateOrGettest-2 - a 409 fetches the existing account, and only diffs it when asked to
+func TestCreateOrGetGetsOnConflict(t *testing.T) {
+	t.Parallel()
+	mockServer := newCreateOrGetServer(http.StatusConflict, http.StatusOK)
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+	requested := testSubmittedAccount()
+	requested.Data.Attributes.BankID = "400302"
+
+	result, err := service.CreateOrGet(requested, CreateOrGetOptions{})
+	assert.Nil(t, err)
+	assert.False(t, result.Created)
+	assert.Equal(t, testAccountID, result.Account.Data.ID)
+	assert.Nil(t, result.Diff)
+
+	result, err = service.CreateOrGet(requested, CreateOrGetOptions{Diff: true})
+	assert.Nil(t, err)
+	assert.False(t, result.Created)
+	assert.Equal(t, []FieldDiff{{Field: "attributes.bank_id", Want: "400302", Got: "400300"}}, result.Diff)
+}
+
+// CreateOrGettest-3 - failures other than a conflict, and a conflict whose account can't be fetched, are errors
+func TestCreateOrGetErrors(t *testing.T) {
+	t.Parallel()
+	mockServer := newCreateOrGetServer(http.StatusBadRequest, http.StatusOK)
+	result, err := NewService(nil, mockServer.URL).CreateOrGet(testSubmittedAccount(), CreateOrGetOptions{})
+	mockServer.Close()
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, apierrors.ErrBadRequest))
+
+	mockServer = newCreateOrGetServer(http.StatusConflict, http.StatusNotFound)
+	result, err = NewService(nil, mockServer.URL).CreateOrGet(testSubmittedAccount(), CreateOrGetOptions{})
+	mockServer.Close()
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, apierrors.ErrNotFound))
+}
@@ -0,0 +1,95 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+)
+
+// options for DeleteLatest
+type DeleteOptions struct {
+	// how many times to fetch the current version again and retry after a version conflict. zero defaults to 3, and
+	// a negative value, e.g. NoConflictRetries, gives up on the first conflict
+	MaxConflictRetries int
+	// treat an account that doesn't exist as deleted, which makes cleanup idempotent
+	IgnoreNotFound bool
+}
+
+// MaxConflictRetries value that turns the conflict retries off
+const NoConflictRetries = -1
+
+// the number of conflict retries the options ask for, with the default filled in
+func (o DeleteOptions) conflictRetries() int {
+	switch {
+	case o.MaxConflictRetries < 0:
+		return 0
+	case o.MaxConflictRetries == 0:
+		return 3
+	}
+	return o.MaxConflictRetries
+}
+
+// passes err through, unless it says the account wasn't found. handy for idempotent cleanup with Delete
+func IgnoreNotFound(err error) error {
+	if errors.Is(err, apierrors.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// delete latest without custom user context. see DeleteLatestWithCtx
+func (s *Service) DeleteLatest(id string, opts DeleteOptions) error {
+	return s.DeleteLatestWithCtx(context.Background(), id, opts)
+}
+
+// deletes an account without the caller having to know its version; it fetches the current version and deletes
+// that. if the account is modified in between, the delete is retried against the new version up to
+// MaxConflictRetries times, after which an error wrapping errors.ErrVersionConflict comes back. every other failure
+// comes back as the *errors.APIError from the backend
+func (s *Service) DeleteLatestWithCtx(ctx context.Context, id string, opts DeleteOptions) error {
+	_, err := s.deleteLatest(ctx, id, opts)
+	return err
+}
+
+// the shape of deleteVersion, for deleteLatest
+type deleteVersionFunc func(ctx context.Context, id string, version int) (*client.Response, error)
+
+// helper function behind DeleteLatest, which hands back the last raw response as well
+func (s *Service) deleteLatest(ctx context.Context, id string, opts DeleteOptions) (*client.Response, error) {
+	return deleteLatest(ctx, id, opts, s.FetchWithCtx, s.deleteVersion)
+}
+
+// the fetch and delete loop behind DeleteLatest, on top of any fetch and versioned delete. the fake runs it too, so
+// both behave the same
+func deleteLatest(ctx context.Context, id string, opts DeleteOptions, fetch fetchFunc,
+	deleteVersion deleteVersionFunc) (*client.Response, error) {
+	retries := opts.conflictRetries()
+	for attempt := 0; ; attempt++ {
+		existing, resp, err := fetch(ctx, id)
+		if err != nil {
+			if opts.IgnoreNotFound {
+				return resp, IgnoreNotFound(err)
+			}
+			return resp, err
+		}
+		var version int64
+		if existing.Data != nil && existing.Data.Version != nil {
+			version = *existing.Data.Version
+		}
+
+		resp, err = deleteVersion(ctx, id, int(version))
+		switch {
+		case err == nil:
+			return resp, nil
+		case opts.IgnoreNotFound && errors.Is(err, apierrors.ErrNotFound):
+			return resp, nil
+		case !errors.Is(err, apierrors.ErrVersionConflict):
+			return resp, err
+		case attempt >= retries:
+			return resp, fmt.Errorf("deleting account %s: still conflicting after %d retries: %w", id, retries, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper backend holding a single account whose version is bumped by somebody else `bumps` times, right after each
+// fetch. fetch and delete mimic the form3 api; a delete with a stale version gets a 409
+type versionedBackend struct {
+	mu       sync.Mutex
+	exists   bool
+	version  int
+	bumps    int
+	deletes  []string
+	failWith int
+}
+
+func (b *versionedBackend) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failWith != 0 {
+		writer.WriteHeader(b.failWith)
+		return
+	}
+	if !b.exists {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		fmt.Fprintf(writer, `{"data": {"id": %q, "version": %d}}`, testAccountID, b.version)
+		if b.bumps > 0 {
+			b.bumps--
+			b.version++
+		}
+	case http.MethodDelete:
+		b.deletes = append(b.deletes, req.URL.Query().Get("version"))
+		if req.URL.Query().Get("version") != fmt.Sprint(b.version) {
+			writer.WriteHeader(http.StatusConflict)
+			return
+		}
+		b.exists = false
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Deletetest-1 - delete latest should delete the current version, fetching it again after each conflict
+func TestDeleteLatest(t *testing.T) {
+	t.Parallel()
+	backend := &versionedBackend{exists: true, version: 4, bumps: 2}
+	mockServer := httptest.NewServer(backend)
+	defer mockServer.Close()
+
+	assert.Nil(t, NewService(nil, mockServer.URL).DeleteLatest(testAccountID, DeleteOptions{}))
+	assert.False(t, backend.exists)
+	assert.Equal(t, []string{"4", "5", "6"}, backend.deletes)
+}
+
+// Deletetest-2 - once the retries are used up, the version conflict comes back
+func TestDeleteLatestGivesUp(t *testing.T) {
+	t.Parallel()
+	backend := &versionedBackend{exists: true, bumps: 10}
+	mockServer := httptest.NewServer(backend)
+	defer mockServer.Close()
+
+	err := NewService(nil, mockServer.URL).DeleteLatest(testAccountID, DeleteOptions{MaxConflictRetries: 2})
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict))
+	assert.True(t, backend.exists)
+	assert.Len(t, backend.deletes, 3)
+}
+
+// Deletetest-3 - a missing account is an error, unless it is asked to be ignored
+func TestDeleteLatestNotFound(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(&versionedBackend{})
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+
+	assert.True(t, errors.Is(service.DeleteLatest(testAccountID, DeleteOptions{}), apierrors.ErrNotFound))
+	assert.Nil(t, service.DeleteLatest(testAccountID, DeleteOptions{IgnoreNotFound: true}))
+	assert.Nil(t, IgnoreNotFound(service.Delete(testAccountID, 0)))
+}
+
+// Deletetest-4 - other failures come back as typed errors, whatever the options
+func TestDeleteLatestFailure(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(&versionedBackend{exists: true, failWith: http.StatusInternalServerError})
+	defer mockServer.Close()
+
+	err := NewService(nil, mockServer.URL).DeleteLatest(testAccountID, DeleteOptions{IgnoreNotFound: true})
+	var apiErr *apierrors.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, apierrors.ErrServerError, apiErr.Code)
+	assert.NotNil(t, IgnoreNotFound(err))
+}
+
+// Deletetest-5 - NoConflictRetries gives up on the first conflict instead of falling back to the default
+func TestDeleteLatestNoConflictRetries(t *testing.T) {
+	t.Parallel()
+	backend := &versionedBackend{exists: true, bumps: 10}
+	mockServer := httptest.NewServer(backend)
+	defer mockServer.Close()
+
+	err := NewService(nil, mockServer.URL).DeleteLatest(testAccountID, DeleteOptions{MaxConflictRetries: NoConflictRetries})
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict))
+	assert.Len(t, backend.deletes, 1)
+	assert.Equal(t, 3, DeleteOptions{}.conflictRetries())
+}
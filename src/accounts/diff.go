@@ -0,0 +1,64 @@
+package accounts
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// FieldDiff is a single field that differs between two accounts, named by its json path, e.g. "attributes.bank_id"
+type FieldDiff struct {
+	Field string
+	Want  interface{}
+	Got   interface{}
+}
+
+// the fields the backend populates on its own, which never match what was submitted
+var serverPopulatedFields = map[string]bool{"version": true, "created_on": true, "modified_on": true}
+
+// compares an account as it was submitted (want) with the account the backend holds (got). only the fields set on
+// want are compared, as the backend is free to fill in the rest, and the server populated version/created_on/
+// modified_on are ignored. the diffs come back sorted by field
+func Diff(want models.Account, got models.Account) []FieldDiff {
+	wantFields, gotFields := flattenAccount(want), flattenAccount(got)
+	var diffs []FieldDiff
+	for field, wantValue := range wantFields {
+		if gotValue := gotFields[field]; !reflect.DeepEqual(wantValue, gotValue) {
+			diffs = append(diffs, FieldDiff{Field: field, Want: wantValue, Got: gotValue})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs
+}
+
+// flattens the data of an account into json paths and their values, dropping the fields that aren't set
+func flattenAccount(acc models.Account) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if acc.Data == nil {
+		return fields
+	}
+	// round trip through json so omitempty decides what is set, and so both sides hold the same types
+	encoded, err := json.Marshal(acc.Data)
+	if err != nil {
+		return fields
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return fields
+	}
+	for key, value := range data {
+		if serverPopulatedFields[key] {
+			continue
+		}
+		if attributes, ok := value.(map[string]interface{}); ok && key == "attributes" {
+			for attribute, attributeValue := range attributes {
+				fields["attributes."+attribute] = attributeValue
+			}
+			continue
+		}
+		fields[key] = value
+	}
+	return fields
+}
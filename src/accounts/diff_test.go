@@ -0,0 +1,34 @@
+package accounts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Difftest-1 - only the submitted fields are compared; what the backend filled in on its own isn't a difference
+func TestDiffIgnoresUnsetAndServerPopulatedFields(t *testing.T) {
+	t.Parallel()
+	stored := models.Account{}
+	assert.Nil(t, json.Unmarshal([]byte(testAccountResponse), &stored))
+	assert.Empty(t, Diff(testSubmittedAccount(), stored))
+}
+
+// Difftest-2 - differences come back per field, sorted, with missing fields reported as nil
+func TestDiffReportsFields(t *testing.T) {
+	t.Parallel()
+	stored := models.Account{}
+	assert.Nil(t, json.Unmarshal([]byte(testAccountResponse), &stored))
+
+	submitted := testSubmittedAccount()
+	submitted.Data.OrganisationID = "another-org"
+	submitted.Data.Attributes.Name = []string{"Sam Holder"}
+	submitted.Data.Attributes.Iban = "GB11NWBK40030041426819"
+	assert.Equal(t, []FieldDiff{
+		{Field: "attributes.iban", Want: "GB11NWBK40030041426819", Got: nil},
+		{Field: "attributes.name", Want: []interface{}{"Sam Holder"}, Got: []interface{}{"Samantha Holder"}},
+		{Field: "organisation_id", Want: "another-org", Got: "4fd712d9-e281-4add-8d66-800f6960b57c"},
+	}, Diff(submitted, stored))
+}
@@ -0,0 +1,553 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/accounts/internal/memstore"
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// the path of the accounts resource, which the list links of a FakeAccountsAPI are relative to like the real api's
+const fakePath = "/v1/organisation/accounts"
+
+// the url the errors of a FakeAccountsAPI carry
+const fakeURL = "fake://" + fakePath
+
+// the subset of *testing.T the assertions need
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// a call a FakeAccountsAPI received. Method is the name of the method without the WithCtx suffix, so both forms are
+// recorded the same way, and Args are its arguments after the context
+type FakeCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// FakeAccountsAPI is an in-memory AccountsAPI for unit tests. out of the box it behaves like the real api: accounts
+// are versioned, a duplicate ID gets a 409, an unknown one a 404 and a stale version a version conflict, all as
+// *errors.APIError. the Func fields and FailWith change that behaviour, and every call is recorded for the assertions.
+// a zero FakeAccountsAPI is ready to use, and it is safe to share between goroutines
+type FakeAccountsAPI struct {
+	// optional overrides, called instead of the in-memory behaviour. each one backs both forms of its method, and the
+	// operations built on top of it, e.g. CreateFunc is also used by CreateOrGet and BulkCreate
+	CreateFunc func(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error)
+	FetchFunc  func(ctx context.Context, id string) (*models.Account, *client.Response, error)
+	UpdateFunc func(ctx context.Context, id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error)
+	DeleteFunc func(ctx context.Context, id string, version int) error
+	// backs ListPage, List and the Find methods, which page through what it returns
+	ListFunc   func(ctx context.Context, filter AccountFilter) ([]models.Account, error)
+	HealthFunc func(ctx context.Context) (*client.HealthCheck, error)
+
+	accounts memstore.Store
+
+	mu    sync.Mutex
+	errs  map[string]error
+	calls []FakeCall
+}
+
+var _ AccountsAPI = (*FakeAccountsAPI)(nil)
+
+// constructor method for a fake holding accs
+func NewFakeAccountsAPI(accs ...models.Account) *FakeAccountsAPI {
+	f := &FakeAccountsAPI{}
+	f.Seed(accs...)
+	return f
+}
+
+// adds accounts straight to the fake, without recording a call. a missing version is filled in as 0
+func (f *FakeAccountsAPI) Seed(accs ...models.Account) {
+	for _, acc := range accs {
+		if acc.Data != nil {
+			f.accounts.Seed(*acc.Data)
+		}
+	}
+}
+
+// the account the fake holds under id, if any
+func (f *FakeAccountsAPI) Account(id string) (models.AccountData, bool) {
+	return f.accounts.Get(id)
+}
+
+// makes every call to method (either form, e.g. "Create" covers CreateWithCtx too) fail with err, before it does
+// anything else. the bulk methods fail every item with it. a nil err undoes it
+func (f *FakeAccountsAPI) FailWith(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = make(map[string]error)
+	}
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// every call the fake received so far, oldest first
+func (f *FakeAccountsAPI) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// the calls to method the fake received, oldest first
+func (f *FakeAccountsAPI) CallsTo(method string) []FakeCall {
+	var matching []FakeCall
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			matching = append(matching, call)
+		}
+	}
+	return matching
+}
+
+// reports an error unless method was called with args. a call matches when its leading arguments equal args, so
+// args can leave out the ones the test doesn't care about
+func (f *FakeAccountsAPI) AssertCalled(t TestingT, method string, args ...interface{}) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	calls := f.CallsTo(method)
+	for _, call := range calls {
+		if leadingArgsEqual(call.Args, args) {
+			return true
+		}
+	}
+	t.Errorf("accounts: expected a call to %s with %v, got %d call(s) to it: %v", method, args, len(calls), calls)
+	return false
+}
+
+// whether the first len(want) of got equal want
+func leadingArgsEqual(got []interface{}, want []interface{}) bool {
+	if len(got) < len(want) {
+		return false
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// reports an error if method was called
+func (f *FakeAccountsAPI) AssertNotCalled(t TestingT, method string) bool {
+	return f.AssertNumberOfCalls(t, method, 0)
+}
+
+// reports an error unless method was called exactly n times
+func (f *FakeAccountsAPI) AssertNumberOfCalls(t TestingT, method string, n int) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	if calls := len(f.CallsTo(method)); calls != n {
+		t.Errorf("accounts: expected %d call(s) to %s, got %d", n, method, calls)
+		return false
+	}
+	return true
+}
+
+// forgets every account, every recorded call and every FailWith. the Func fields are left alone
+func (f *FakeAccountsAPI) Reset() {
+	f.accounts.Reset()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs, f.calls = nil, nil
+}
+
+func (f *FakeAccountsAPI) Create(acc models.Account) (*models.Account, *client.Response, error) {
+	return f.CreateWithCtx(context.Background(), acc)
+}
+
+func (f *FakeAccountsAPI) CreateWithCtx(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error) {
+	if err := f.record("Create", acc); err != nil {
+		return nil, fakeResponse(0, err), err
+	}
+	return f.create(ctx, acc)
+}
+
+func (f *FakeAccountsAPI) Fetch(id string) (*models.Account, *client.Response, error) {
+	return f.FetchWithCtx(context.Background(), id)
+}
+
+func (f *FakeAccountsAPI) FetchWithCtx(ctx context.Context, id string) (*models.Account, *client.Response, error) {
+	if err := f.record("Fetch", id); err != nil {
+		return nil, fakeResponse(0, err), err
+	}
+	return f.fetch(ctx, id)
+}
+
+func (f *FakeAccountsAPI) Update(id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error) {
+	return f.UpdateWithCtx(context.Background(), id, version, patch)
+}
+
+func (f *FakeAccountsAPI) UpdateWithCtx(ctx context.Context, id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error) {
+	if err := f.record("Update", id, version, patch); err != nil {
+		return nil, fakeResponse(0, err), err
+	}
+	return f.update(ctx, id, version, patch)
+}
+
+func (f *FakeAccountsAPI) Delete(id string, version int) error {
+	return f.DeleteWithCtx(context.Background(), id, version)
+}
+
+func (f *FakeAccountsAPI) DeleteWithCtx(ctx context.Context, id string, version int) error {
+	if err := f.record("Delete", id, version); err != nil {
+		return err
+	}
+	return f.delete(ctx, id, version)
+}
+
+func (f *FakeAccountsAPI) ListPage(opts ListOptions) (*models.AccountList, *client.Response, error) {
+	return f.ListPageWithCtx(context.Background(), opts)
+}
+
+// the page of the accounts matching the filter that opts asks for, with first/last/self/prev/next links like the
+// real api's. the page size defaults to 100, like the real api
+func (f *FakeAccountsAPI) ListPageWithCtx(ctx context.Context, opts ListOptions) (*models.AccountList, *client.Response, error) {
+	if err := f.record("ListPage", opts); err != nil {
+		return nil, fakeResponse(0, err), err
+	}
+	accs, err := f.list(ctx, opts.Filter)
+	if err != nil {
+		return nil, fakeResponse(0, err), err
+	}
+	matching := make([]models.AccountData, 0, len(accs))
+	for _, acc := range accs {
+		matching = append(matching, *acc.Data)
+	}
+	size := fakePageSize(opts)
+	page := &models.AccountList{
+		Data:  []models.AccountData{},
+		Links: memstore.Links(fakePath, opts.Filter.Values(), opts.PageNumber, size, len(matching)),
+	}
+	if data := memstore.Page(matching, opts.PageNumber, size); data != nil {
+		page.Data = data
+	}
+	return page, fakeResponse(http.StatusOK, nil), nil
+}
+
+func (f *FakeAccountsAPI) List(opts ListOptions) *AccountIterator {
+	return f.ListWithCtx(context.Background(), opts)
+}
+
+// an iterator over the accounts matching the filter, starting at the page described by opts. the page size defaults to
+// 100, like the real api
+func (f *FakeAccountsAPI) ListWithCtx(ctx context.Context, opts ListOptions) *AccountIterator {
+	if err := f.record("List", opts); err != nil {
+		return NewAccountIterator(nil, err)
+	}
+	accs, err := f.list(ctx, opts.Filter)
+	if start := opts.PageNumber * fakePageSize(opts); start > 0 {
+		if start > len(accs) {
+			start = len(accs)
+		}
+		accs = accs[start:]
+	}
+	return NewAccountIterator(accs, err)
+}
+
+func (f *FakeAccountsAPI) Find(filter AccountFilter) ([]models.Account, error) {
+	return f.FindWithCtx(context.Background(), filter)
+}
+
+func (f *FakeAccountsAPI) FindWithCtx(ctx context.Context, filter AccountFilter) ([]models.Account, error) {
+	if err := f.record("Find", filter); err != nil {
+		return nil, err
+	}
+	return f.list(ctx, filter)
+}
+
+func (f *FakeAccountsAPI) FindByIBAN(iban string) ([]models.Account, error) {
+	return f.FindByIBANWithCtx(context.Background(), iban)
+}
+
+func (f *FakeAccountsAPI) FindByIBANWithCtx(ctx context.Context, iban string) ([]models.Account, error) {
+	if err := f.record("FindByIBAN", iban); err != nil {
+		return nil, err
+	}
+	return f.list(ctx, AccountFilter{IBAN: []string{iban}})
+}
+
+func (f *FakeAccountsAPI) FindByAccountNumber(accountNumber string) ([]models.Account, error) {
+	return f.FindByAccountNumberWithCtx(context.Background(), accountNumber)
+}
+
+func (f *FakeAccountsAPI) FindByAccountNumberWithCtx(ctx context.Context, accountNumber string) ([]models.Account, error) {
+	if err := f.record("FindByAccountNumber", accountNumber); err != nil {
+		return nil, err
+	}
+	return f.list(ctx, AccountFilter{AccountNumber: []string{accountNumber}})
+}
+
+func (f *FakeAccountsAPI) ResolveCreate(acc models.Account) (*CreateResolution, error) {
+	return f.ResolveCreateWithCtx(context.Background(), acc)
+}
+
+// same semantics as Service.ResolveCreateWithCtx, on top of the fake's Fetch
+func (f *FakeAccountsAPI) ResolveCreateWithCtx(ctx context.Context, acc models.Account) (*CreateResolution, error) {
+	if err := f.record("ResolveCreate", acc); err != nil {
+		return nil, err
+	}
+	return resolveCreate(ctx, acc, f.fetch)
+}
+
+func (f *FakeAccountsAPI) CreateOrGet(acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error) {
+	return f.CreateOrGetWithCtx(context.Background(), acc, opts)
+}
+
+// same semantics as Service.CreateOrGetWithCtx, on top of the fake's Create and Fetch
+func (f *FakeAccountsAPI) CreateOrGetWithCtx(ctx context.Context, acc models.Account, opts CreateOrGetOptions) (*CreateOrGetResult, error) {
+	if err := f.record("CreateOrGet", acc, opts); err != nil {
+		return nil, err
+	}
+	return createOrGet(ctx, acc, opts, f.create, f.fetch)
+}
+
+func (f *FakeAccountsAPI) DeleteLatest(id string, opts DeleteOptions) error {
+	return f.DeleteLatestWithCtx(context.Background(), id, opts)
+}
+
+// same semantics as Service.DeleteLatestWithCtx, on top of the fake's Fetch and Delete
+func (f *FakeAccountsAPI) DeleteLatestWithCtx(ctx context.Context, id string, opts DeleteOptions) error {
+	if err := f.record("DeleteLatest", id, opts); err != nil {
+		return err
+	}
+	return f.deleteLatest(ctx, id, opts)
+}
+
+func (f *FakeAccountsAPI) BulkCreate(ctx context.Context, accs []models.Account, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, accountItems(accs), opts, f.bulk(f.record("BulkCreate", accs, opts), f.bulkCreate))
+}
+
+func (f *FakeAccountsAPI) BulkCreateFrom(ctx context.Context, accs <-chan models.Account, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, accountItemsFrom(accs), opts, f.bulk(f.record("BulkCreateFrom", accs, opts), f.bulkCreate))
+}
+
+func (f *FakeAccountsAPI) BulkFetch(ctx context.Context, ids []string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItems(ids), opts, f.bulk(f.record("BulkFetch", ids, opts), f.bulkFetch))
+}
+
+func (f *FakeAccountsAPI) BulkFetchFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItemsFrom(ids), opts, f.bulk(f.record("BulkFetchFrom", ids, opts), f.bulkFetch))
+}
+
+func (f *FakeAccountsAPI) BulkDelete(ctx context.Context, ids []string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItems(ids), opts, f.bulk(f.record("BulkDelete", ids, opts), f.bulkDelete))
+}
+
+func (f *FakeAccountsAPI) BulkDeleteFrom(ctx context.Context, ids <-chan string, opts BulkOptions) *BulkReport {
+	return runBulk(ctx, idItemsFrom(ids), opts, f.bulk(f.record("BulkDeleteFrom", ids, opts), f.bulkDelete))
+}
+
+// the api is up, unless HealthFunc or FailWith say otherwise
+func (f *FakeAccountsAPI) Health(ctx context.Context) (*client.HealthCheck, error) {
+	if err := f.record("Health"); err != nil {
+		return nil, err
+	}
+	return f.health(ctx)
+}
+
+// polls the fake's health until it is ready or ctx is done, with the same default interval and backoff as
+// client.Client.WaitUntilReady
+func (f *FakeAccountsAPI) WaitUntilReady(ctx context.Context, pollInterval time.Duration) error {
+	if err := f.record("WaitUntilReady", pollInterval); err != nil {
+		return err
+	}
+	return client.PollUntilReady(ctx, "the fake accounts api", pollInterval, f.health)
+}
+
+// records a call, and returns the error FailWith set for its method, if any
+func (f *FakeAccountsAPI) record(method string, args ...interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, FakeCall{Method: method, Args: args})
+	return f.errs[method]
+}
+
+func (f *FakeAccountsAPI) create(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if f.CreateFunc != nil {
+		return f.CreateFunc(ctx, acc)
+	}
+	if acc.Data == nil || acc.Data.ID == "" {
+		err := fakeError(http.MethodPost, "", http.StatusBadRequest, "invalid account data")
+		return nil, fakeResponse(0, err), err
+	}
+
+	data, err := f.accounts.Create(*acc.Data)
+	if err != nil {
+		err = fakeError(http.MethodPost, "", http.StatusConflict, "Account cannot be created as it violates a duplicate constraint")
+		return nil, fakeResponse(0, err), err
+	}
+	return &models.Account{Data: &data}, fakeResponse(http.StatusCreated, nil), nil
+}
+
+func (f *FakeAccountsAPI) fetch(ctx context.Context, id string) (*models.Account, *client.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if f.FetchFunc != nil {
+		return f.FetchFunc(ctx, id)
+	}
+	data, ok := f.accounts.Get(id)
+	if !ok {
+		err := fakeError(http.MethodGet, id, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+		return nil, fakeResponse(0, err), err
+	}
+	return &models.Account{Data: &data}, fakeResponse(http.StatusOK, nil), nil
+}
+
+// like the real PATCH, only the attributes set in the patch are changed
+func (f *FakeAccountsAPI) update(ctx context.Context, id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if f.UpdateFunc != nil {
+		return f.UpdateFunc(ctx, id, version, patch)
+	}
+	// the attributes set in the patch, as the real PATCH body would carry them
+	attributes := make(map[string]json.RawMessage)
+	encoded, _ := json.Marshal(patch)
+	json.Unmarshal(encoded, &attributes)
+
+	data, err := f.accounts.Patch(id, int64(version), attributes)
+	switch err {
+	case nil:
+		return &models.Account{Data: &data}, fakeResponse(http.StatusOK, nil), nil
+	case memstore.ErrNotFound:
+		err = fakeError(http.MethodPatch, id, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+	case memstore.ErrVersion:
+		err = asVersionConflict(fakeError(http.MethodPatch, id, http.StatusConflict, "invalid version"))
+	default:
+		err = fakeError(http.MethodPatch, id, http.StatusBadRequest, "invalid account attributes")
+	}
+	return nil, fakeResponse(0, err), err
+}
+
+func (f *FakeAccountsAPI) delete(ctx context.Context, id string, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.DeleteFunc != nil {
+		return f.DeleteFunc(ctx, id, version)
+	}
+	switch f.accounts.Delete(id, int64(version)) {
+	case nil:
+		return nil
+	case memstore.ErrNotFound:
+		return fakeError(http.MethodDelete, id, http.StatusNotFound, "")
+	default:
+		return asVersionConflict(fakeError(http.MethodDelete, id, http.StatusConflict, "invalid version"))
+	}
+}
+
+// the accounts matching the filter, in creation order. customer_id isn't on the account model, so that filter is
+// ignored
+func (f *FakeAccountsAPI) list(ctx context.Context, filter AccountFilter) ([]models.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.ListFunc != nil {
+		return f.ListFunc(ctx, filter)
+	}
+	var found []models.Account
+	for _, data := range f.accounts.Find(filter.Values()) {
+		data := data
+		found = append(found, models.Account{Data: &data})
+	}
+	return found, nil
+}
+
+func (f *FakeAccountsAPI) deleteLatest(ctx context.Context, id string, opts DeleteOptions) error {
+	_, err := deleteLatest(ctx, id, opts, f.fetch, func(ctx context.Context, id string, version int) (*client.Response, error) {
+		err := f.delete(ctx, id, version)
+		return fakeResponse(http.StatusNoContent, err), err
+	})
+	return err
+}
+
+func (f *FakeAccountsAPI) health(ctx context.Context) (*client.HealthCheck, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.HealthFunc != nil {
+		return f.HealthFunc(ctx)
+	}
+	return &client.HealthCheck{Status: client.HealthUp, StatusCode: http.StatusOK}, nil
+}
+
+// wraps the per item function of a bulk operation, so every item fails with err if FailWith set one
+func (f *FakeAccountsAPI) bulk(err error, do func(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult) func(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+	if err == nil {
+		return do
+	}
+	return func(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+		return BulkResult{ID: item.id, Err: err}
+	}
+}
+
+func (f *FakeAccountsAPI) bulkCreate(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+	created, resp, err := f.create(ctx, item.acc)
+	return BulkResult{ID: item.id, Account: created, StatusCode: statusCode(resp), Err: err}
+}
+
+func (f *FakeAccountsAPI) bulkFetch(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+	fetched, resp, err := f.fetch(ctx, item.id)
+	return BulkResult{ID: item.id, Account: fetched, StatusCode: statusCode(resp), Err: err}
+}
+
+func (f *FakeAccountsAPI) bulkDelete(ctx context.Context, item bulkItem, opts BulkOptions) BulkResult {
+	err := f.deleteLatest(ctx, item.id, opts.Delete)
+	status := http.StatusNoContent
+	if err != nil {
+		status = statusCode(fakeResponse(0, err))
+	}
+	return BulkResult{ID: item.id, StatusCode: status, Err: err}
+}
+
+// the page size opts ask for, or the real api's default of 100
+func fakePageSize(opts ListOptions) int {
+	if opts.PageSize <= 0 {
+		return 100
+	}
+	return opts.PageSize
+}
+
+// an *errors.APIError like the real api would have answered with
+func fakeError(method string, id string, status int, message string) error {
+	url := fakeURL
+	if id != "" {
+		url += "/" + id
+	}
+	var body []byte
+	if message != "" {
+		body, _ = json.Marshal(map[string]string{"error_message": message})
+	}
+	return apierrors.NewAPIError(method, url, status, nil, body)
+}
+
+// a bodiless response with status, or with the status of err if it is an *errors.APIError. nil for any other error
+func fakeResponse(status int, err error) *client.Response {
+	var apiErr *apierrors.APIError
+	if errors.As(err, &apiErr) {
+		status = apiErr.StatusCode
+	} else if err != nil {
+		return nil
+	}
+	return &client.Response{StatusCode: status, Headers: http.Header{}}
+}
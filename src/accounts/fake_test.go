@@ -0,0 +1,276 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// records the failures an assertion reports, instead of failing the test
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// helper function that returns a new account with a random id
+func newFakeAccount(bankID string) models.Account {
+	country := "GB"
+	return models.Account{Data: &models.AccountData{
+		ID:             uuid.New().String(),
+		OrganisationID: uuid.New().String(),
+		Type:           "accounts",
+		Attributes: &models.AccountAttributes{
+			BankID:  bankID,
+			Country: &country,
+			Name:    []string{"Samantha Holder"},
+		},
+	}}
+}
+
+// Faketest-1 - out of the box the fake should behave like the real api, versions and typed errors included
+func TestFakeAccountsAPILifecycle(t *testing.T) {
+	t.Parallel()
+	var api AccountsAPI = NewFakeAccountsAPI()
+	acc := newFakeAccount("400300")
+	id := acc.Data.ID
+
+	created, resp, err := api.Create(acc)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int64(0), *created.Data.Version)
+
+	_, resp, err = api.Create(acc)
+	assert.True(t, errors.Is(err, apierrors.ErrConflict))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	updated, _, err := api.UpdateWithCtx(context.Background(), id, 0, models.AccountAttributes{BankID: "400302"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), *updated.Data.Version)
+	assert.Equal(t, "400302", updated.Data.Attributes.BankID)
+	assert.Equal(t, []string{"Samantha Holder"}, updated.Data.Attributes.Name)
+
+	_, _, err = api.Update(id, 0, models.AccountAttributes{BankID: "400303"})
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict))
+	assert.True(t, errors.Is(api.Delete(id, 0), apierrors.ErrVersionConflict))
+
+	assert.Nil(t, api.DeleteLatest(id, DeleteOptions{}))
+	_, resp, err = api.Fetch(id)
+	assert.True(t, errors.Is(err, apierrors.ErrNotFound))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Nil(t, api.DeleteLatest(id, DeleteOptions{IgnoreNotFound: true}))
+}
+
+// Faketest-2 - listing and finding should filter and page through the seeded accounts in order
+func TestFakeAccountsAPIList(t *testing.T) {
+	t.Parallel()
+	var accs []models.Account
+	for i := 0; i < 5; i++ {
+		bankID := "400300"
+		if i%2 == 1 {
+			bankID = "400302"
+		}
+		accs = append(accs, newFakeAccount(bankID))
+	}
+	accs[3].Data.Attributes.Iban = "GB33BUKB20201555555555"
+	api := NewFakeAccountsAPI(accs...)
+
+	page, _, err := api.ListPage(ListOptions{PageNumber: 1, PageSize: 2})
+	assert.Nil(t, err)
+	if assert.Len(t, page.Data, 2) {
+		assert.Equal(t, accs[2].Data.ID, page.Data[0].ID)
+	}
+
+	var listed []string
+	it := api.List(ListOptions{PageNumber: 1, PageSize: 2})
+	for it.Next() {
+		listed = append(listed, it.Account().Data.ID)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{accs[2].Data.ID, accs[3].Data.ID, accs[4].Data.ID}, listed)
+
+	found, err := api.Find(AccountFilter{BankID: []string{"400302"}})
+	assert.Nil(t, err)
+	assert.Len(t, found, 2)
+	found, err = api.FindByIBAN("GB33BUKB20201555555555")
+	assert.Nil(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, accs[3].Data.ID, found[0].Data.ID)
+	}
+}
+
+// Faketest-3 - the Func overrides and FailWith should change what the fake answers, for the operations built on top too
+func TestFakeAccountsAPIBehaviour(t *testing.T) {
+	t.Parallel()
+	acc := newFakeAccount("400300")
+	api := NewFakeAccountsAPI(acc)
+	boom := errors.New("boom")
+
+	api.FailWith("Fetch", boom)
+	_, resp, err := api.FetchWithCtx(context.Background(), acc.Data.ID)
+	assert.Equal(t, boom, err)
+	assert.Nil(t, resp)
+	report := api.BulkFetch(context.Background(), []string{acc.Data.ID}, BulkOptions{})
+	assert.Nil(t, report.FirstErr(), "FailWith only applies to the method it names")
+	api.FailWith("Fetch", nil)
+
+	api.FailWith("BulkCreate", boom)
+	report = api.BulkCreate(context.Background(), []models.Account{newFakeAccount("400300"), newFakeAccount("400300")}, BulkOptions{})
+	assert.Equal(t, 2, report.Stats.Failed)
+	assert.Equal(t, boom, report.FirstErr())
+
+	api.CreateFunc = func(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error) {
+		return nil, nil, apierrors.NewAPIError(http.MethodPost, fakeURL, http.StatusConflict, nil, nil)
+	}
+	result, err := api.CreateOrGet(acc, CreateOrGetOptions{Diff: true})
+	assert.Nil(t, err)
+	assert.False(t, result.Created)
+	assert.Empty(t, result.Diff)
+
+	api.HealthFunc = func(ctx context.Context) (*client.HealthCheck, error) {
+		return &client.HealthCheck{Status: client.HealthDown, StatusCode: http.StatusServiceUnavailable}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, api.WaitUntilReady(ctx, 5*time.Millisecond), context.DeadlineExceeded)
+}
+
+// Faketest-4 - every call should be recorded under its method name, whichever form was called, for the assertions
+func TestFakeAccountsAPIAssertions(t *testing.T) {
+	t.Parallel()
+	api := &FakeAccountsAPI{}
+	acc := newFakeAccount("400300")
+	api.Create(acc)
+	api.FetchWithCtx(context.Background(), acc.Data.ID)
+	api.Fetch(acc.Data.ID)
+	api.ResolveCreate(acc)
+
+	assert.True(t, api.AssertCalled(t, "Create", acc))
+	assert.True(t, api.AssertCalled(t, "Fetch"))
+	assert.True(t, api.AssertNumberOfCalls(t, "Fetch", 2))
+	assert.True(t, api.AssertNotCalled(t, "Delete"))
+	assert.Len(t, api.Calls(), 4, "the fetch behind ResolveCreate isn't recorded as a call of its own")
+
+	recorder := &recordingT{}
+	assert.False(t, api.AssertCalled(recorder, "Fetch", "another-id"))
+	assert.False(t, api.AssertNumberOfCalls(recorder, "Create", 2))
+	assert.False(t, api.AssertNotCalled(recorder, "ResolveCreate"))
+	assert.Len(t, recorder.errors, 3)
+
+	api.Reset()
+	assert.Empty(t, api.Calls())
+	_, ok := api.Account(acc.Data.ID)
+	assert.False(t, ok)
+}
+
+// Faketest-5 - an iterator over a fixed set of accounts should yield them all, then its error
+func TestNewAccountIterator(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	it := NewAccountIterator([]models.Account{newFakeAccount("400300"), newFakeAccount("400302")}, boom)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, boom, it.Err())
+}
+
+// Faketest-6 - without a page size, List starts from the page the real api's default size of 100 would give
+func TestFakeAccountsAPIListDefaultPageSize(t *testing.T) {
+	t.Parallel()
+	api := NewFakeAccountsAPI(newFakeAccount("400300"), newFakeAccount("400302"))
+	it := api.List(ListOptions{PageNumber: 1})
+	assert.False(t, it.Next(), "page 1 of 100 is past the 2 accounts")
+	assert.Nil(t, it.Err())
+
+	it = api.List(ListOptions{})
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
+
+// Faketest-7 - DeleteLatest runs the same conflict retry loop as the service, NoConflictRetries included
+func TestFakeAccountsAPIDeleteLatestConflicts(t *testing.T) {
+	t.Parallel()
+	acc := newFakeAccount("400300")
+	api := NewFakeAccountsAPI(acc)
+	deletes := 0
+	api.DeleteFunc = func(ctx context.Context, id string, version int) error {
+		deletes++
+		return asVersionConflict(fakeError(http.MethodDelete, id, http.StatusConflict, "invalid version"))
+	}
+
+	err := api.DeleteLatest(acc.Data.ID, DeleteOptions{MaxConflictRetries: NoConflictRetries})
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict))
+	assert.Equal(t, 1, deletes)
+
+	err = api.DeleteLatest(acc.Data.ID, DeleteOptions{})
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict))
+	assert.Equal(t, 5, deletes, "the default is 3 retries on top of the first delete")
+}
+
+// Faketest-8 - WaitUntilReady without a poll interval waits a second between polls like the client does, rather than
+// spinning
+func TestFakeAccountsAPIWaitUntilReadyDefaultInterval(t *testing.T) {
+	t.Parallel()
+	api := NewFakeAccountsAPI()
+	polls := 0
+	api.HealthFunc = func(ctx context.Context) (*client.HealthCheck, error) {
+		polls++
+		return &client.HealthCheck{Status: "down", StatusCode: http.StatusServiceUnavailable}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, api.WaitUntilReady(ctx, 0), context.DeadlineExceeded)
+	assert.Equal(t, 1, polls)
+}
+
+// Faketest-9 - ListPage carries links like the real api, so a consumer following links.next reads every page
+func TestFakeAccountsAPIListPageLinks(t *testing.T) {
+	t.Parallel()
+	var accs []models.Account
+	for i := 0; i < 5; i++ {
+		accs = append(accs, newFakeAccount("400300"))
+	}
+	api := NewFakeAccountsAPI(accs...)
+
+	opts := ListOptions{PageSize: 2, Filter: AccountFilter{BankID: []string{"400300"}}}
+	var listed []string
+	for pages := 0; pages < 10; pages++ {
+		page, _, err := api.ListPage(opts)
+		if !assert.Nil(t, err) || !assert.NotNil(t, page.Links) {
+			return
+		}
+		for _, data := range page.Data {
+			listed = append(listed, data.ID)
+		}
+		if page.Links.Next == "" {
+			break
+		}
+		next, err := url.Parse(page.Links.Next)
+		assert.Nil(t, err)
+		assert.Equal(t, "400300", next.Query().Get("filter[bank_id]"))
+		opts.PageNumber, _ = strconv.Atoi(next.Query().Get("page[number]"))
+	}
+	assert.Len(t, listed, 5)
+
+	page, _, _ := api.ListPage(ListOptions{PageNumber: 1, PageSize: 2})
+	assert.Contains(t, page.Links.Prev, "page%5Bnumber%5D=0")
+	assert.Contains(t, page.Links.Last, "page%5Bnumber%5D=2")
+}
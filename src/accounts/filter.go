@@ -0,0 +1,74 @@
+package accounts
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// AccountFilter narrows down List to the accounts matching every non-empty field. a field with more than one value
+// matches any of them, e.g. Country: []string{"GB", "FR"}
+type AccountFilter struct {
+	BankID        []string
+	BankIDCode    []string
+	AccountNumber []string
+	IBAN          []string
+	Country       []string
+	CustomerID    []string
+}
+
+// renders the filter into filter[...] query params, one entry per value
+func (f AccountFilter) Values() url.Values {
+	values := url.Values{}
+	add := func(key string, filterValues []string) {
+		for _, value := range filterValues {
+			values.Add("filter["+key+"]", value)
+		}
+	}
+	add("bank_id", f.BankID)
+	add("bank_id_code", f.BankIDCode)
+	add("account_number", f.AccountNumber)
+	add("iban", f.IBAN)
+	add("country", f.Country)
+	add("customer_id", f.CustomerID)
+	return values
+}
+
+// returns every account matching the filter, across all pages
+func (s *Service) Find(filter AccountFilter) ([]models.Account, error) {
+	return s.FindWithCtx(context.Background(), filter)
+}
+
+// Find with a custom context
+func (s *Service) FindWithCtx(ctx context.Context, filter AccountFilter) ([]models.Account, error) {
+	var found []models.Account
+	it := s.ListWithCtx(ctx, ListOptions{Filter: filter})
+	for it.Next() {
+		found = append(found, *it.Account())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// returns the accounts with the given iban
+func (s *Service) FindByIBAN(iban string) ([]models.Account, error) {
+	return s.FindByIBANWithCtx(context.Background(), iban)
+}
+
+// FindByIBAN with a custom context
+func (s *Service) FindByIBANWithCtx(ctx context.Context, iban string) ([]models.Account, error) {
+	return s.FindWithCtx(ctx, AccountFilter{IBAN: []string{iban}})
+}
+
+// returns the accounts with the given account number
+func (s *Service) FindByAccountNumber(accountNumber string) ([]models.Account, error) {
+	return s.FindByAccountNumberWithCtx(context.Background(), accountNumber)
+}
+
+// FindByAccountNumber with a custom context
+func (s *Service) FindByAccountNumberWithCtx(ctx context.Context, accountNumber string) ([]models.Account, error) {
+	return s.FindWithCtx(ctx, AccountFilter{AccountNumber: []string{accountNumber}})
+}
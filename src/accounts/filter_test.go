@@ -0,0 +1,88 @@
+package accounts
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Filtertest-1 - every field should render to its own filter[...] key, with one entry per value
+func TestAccountFilterValues(t *testing.T) {
+	t.Parallel()
+	filter := AccountFilter{
+		BankID:        []string{"400300"},
+		BankIDCode:    []string{"GBDSC"},
+		AccountNumber: []string{"41426819"},
+		IBAN:          []string{"GB11NWBK40030041426819"},
+		Country:       []string{"GB", "FR"},
+		CustomerID:    []string{"customer-1"},
+	}
+	assert.Equal(t, url.Values{
+		"filter[bank_id]":        []string{"400300"},
+		"filter[bank_id_code]":   []string{"GBDSC"},
+		"filter[account_number]": []string{"41426819"},
+		"filter[iban]":           []string{"GB11NWBK40030041426819"},
+		"filter[country]":        []string{"GB", "FR"},
+		"filter[customer_id]":    []string{"customer-1"},
+	}, filter.Values())
+	assert.Empty(t, AccountFilter{}.Values())
+}
+
+// Filtertest-2 - filters should reach the server on the first page and every page after it
+func TestListWithFilter(t *testing.T) {
+	t.Parallel()
+	queries := make(chan url.Values, 2)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		queries <- req.URL.Query()
+		if req.URL.Query().Get("page[number]") == "1" {
+			fmt.Fprint(writer, `{"data": [{"id": "account-1"}]}`)
+			return
+		}
+		// the next link deliberately leaves the filter out
+		fmt.Fprint(writer, `{"data": [{"id": "account-0"}], "links": {"next": "?page[number]=1"}}`)
+	}))
+	defer mockServer.Close()
+
+	found, err := NewService(nil, mockServer.URL).Find(AccountFilter{Country: []string{"GB", "FR"}})
+	assert.Nil(t, err)
+	assert.Len(t, found, 2)
+
+	close(queries)
+	for query := range queries {
+		assert.Equal(t, []string{"GB", "FR"}, query["filter[country]"])
+	}
+}
+
+// Filtertest-3 - the convenience lookups should filter on the right key
+func TestFindByHelpers(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if iban := req.URL.Query().Get("filter[iban]"); iban != "" {
+			fmt.Fprintf(writer, `{"data": [{"id": "by-iban", "attributes": {"iban": %q}}]}`, iban)
+			return
+		}
+		if number := req.URL.Query().Get("filter[account_number]"); number != "" {
+			fmt.Fprintf(writer, `{"data": [{"id": "by-number", "attributes": {"account_number": %q}}]}`, number)
+			return
+		}
+		fmt.Fprint(writer, `{"data": []}`)
+	}))
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+
+	found, err := service.FindByIBAN("GB11NWBK40030041426819")
+	assert.Nil(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "GB11NWBK40030041426819", found[0].Data.Attributes.Iban)
+	}
+
+	found, err = service.FindByAccountNumber("41426819")
+	assert.Nil(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "41426819", found[0].Data.Attributes.AccountNumber)
+	}
+}
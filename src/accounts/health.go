@@ -0,0 +1,32 @@
+package accounts
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+)
+
+// where the accounts api serves its health check, on the same host as the accounts resource
+const healthPath = "/v1/health"
+
+// the health endpoint of the api this service talks to, e.g. http://localhost:8080/v1/health for the default base URL
+func (s *Service) HealthURL() string {
+	u, err := url.Parse(s.BaseURL())
+	if err != nil {
+		return healthPath
+	}
+	u.Path, u.RawPath, u.RawQuery, u.Fragment = healthPath, "", "", ""
+	return u.String()
+}
+
+// checks the health of the accounts api. see client.Client.Health
+func (s *Service) Health(ctx context.Context) (*client.HealthCheck, error) {
+	return s.client.Health(ctx, s.HealthURL())
+}
+
+// blocks until the accounts api reports healthy, or ctx is done. see client.Client.WaitUntilReady
+func (s *Service) WaitUntilReady(ctx context.Context, pollInterval time.Duration) error {
+	return s.client.WaitUntilReady(ctx, s.HealthURL(), pollInterval)
+}
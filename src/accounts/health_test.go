@@ -0,0 +1,37 @@
+package accounts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Healthtest-1 - the health endpoint lives on the same host as the accounts resource
+func TestHealthURL(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "http://localhost:8080/v1/health", NewService(nil, "http://localhost:8080/v1/organisation/accounts").HealthURL())
+	assert.Equal(t, "https://api.example.com/v1/health", NewService(nil, "https://api.example.com/v1/organisation/accounts?foo=bar").HealthURL())
+}
+
+// Healthtest-2 - health and readiness should go to /v1/health
+func TestHealthAndWaitUntilReady(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v1/health", req.URL.Path)
+		writer.Write([]byte(`{"status": "up"}`))
+	}))
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL+"/v1/organisation/accounts")
+
+	health, err := service.Health(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, health.Ready())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, service.WaitUntilReady(ctx, 10*time.Millisecond))
+}
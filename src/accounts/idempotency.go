@@ -0,0 +1,66 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+var errNoAccountID = errors.New("account has no id to resolve the create by")
+
+type idempotencyKeyKey struct{}
+
+// tags a context with the Idempotency-Key to send on Create, instead of the one Create generates on its own. useful
+// to carry the same key across process restarts, e.g. when the key is stored alongside an outbox record
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// returns the Idempotency-Key a context was tagged with via WithIdempotencyKey(), or an empty string
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
+
+// the outcome of ResolveCreate
+type CreateResolution struct {
+	// the account the backend holds under the submitted ID, nil if there is none
+	Account *models.Account
+	// fields where the account the backend holds differs from the submitted one, see Diff()
+	Diff []FieldDiff
+}
+
+// whether the create landed, i.e. the backend holds an account under the submitted ID that matches it
+func (r *CreateResolution) Created() bool {
+	return r.Account != nil && len(r.Diff) == 0
+}
+
+// resolve an ambiguous create without custom user context. see ResolveCreateWithCtx
+func (s *Service) ResolveCreate(acc models.Account) (*CreateResolution, error) {
+	return s.ResolveCreateWithCtx(context.Background(), acc)
+}
+
+// works out what happened to a Create that timed out or failed without an answer, by fetching the account by its ID
+// and comparing it with what was submitted. no account means the create never landed and is safe to send again. an
+// account that matches means it landed. an account that doesn't match belongs to somebody else, and Diff says how it
+// differs. an error is only returned when the backend can't tell us either way
+func (s *Service) ResolveCreateWithCtx(ctx context.Context, acc models.Account) (*CreateResolution, error) {
+	return resolveCreate(ctx, acc, s.FetchWithCtx)
+}
+
+// the lookup behind ResolveCreate, on top of any fetch. the fake runs it too, so both behave the same
+func resolveCreate(ctx context.Context, acc models.Account, fetch fetchFunc) (*CreateResolution, error) {
+	if acc.Data == nil || acc.Data.ID == "" {
+		return nil, errNoAccountID
+	}
+	existing, _, err := fetch(ctx, acc.Data.ID)
+	if errors.Is(err, apierrors.ErrNotFound) {
+		return &CreateResolution{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &CreateResolution{Account: existing, Diff: Diff(acc, *existing)}, nil
+}
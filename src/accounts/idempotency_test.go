@@ -0,0 +1,159 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that returns the account testAccountResponse was created from
+func testSubmittedAccount() models.Account {
+	country := "GB"
+	return models.Account{Data: &models.AccountData{
+		ID:             testAccountID,
+		OrganisationID: "4fd712d9-e281-4add-8d66-800f6960b57c",
+		Type:           "accounts",
+		Attributes: &models.AccountAttributes{
+			BankID:  "400300",
+			Country: &country,
+			Name:    []string{"Samantha Holder"},
+		},
+	}}
+}
+
+// Idempotencytest-1 - every create call gets its own Idempotency-Key, which is kept across retries of that call
+func TestCreateSendsIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var keys []string
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		keys = append(keys, req.Header.Get(client.IdempotencyKeyHeader))
+		mu.Unlock()
+		if atomic.AddInt32(&calls, 1) == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusCreated)
+		fmt.Fprint(writer, testAccountResponse)
+	}))
+	defer mockServer.Close()
+
+	c := client.NewDefaultClient()
+	c.SetRetryPolicy(&client.RetryPolicy{
+		MaxAttempts:           2,
+		BaseDelay:             time.Millisecond,
+		RetryableStatusCodes:  map[int]bool{http.StatusServiceUnavailable: true},
+		RetryIdempotencyKeyed: true,
+	})
+	service := NewService(c, mockServer.URL)
+
+	_, _, err := service.Create(testSubmittedAccount())
+	assert.Nil(t, err)
+	_, _, err = service.Create(testSubmittedAccount())
+	assert.Nil(t, err)
+
+	assert.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1], "a retry should reuse the key of its call")
+	assert.NotEqual(t, keys[1], keys[2], "a new call should get a new key")
+}
+
+// Idempotencytest-2 - a key set on the context wins over the generated one
+func TestCreateWithCallerIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	received := make(chan string, 1)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		received <- req.Header.Get(client.IdempotencyKeyHeader)
+		writer.WriteHeader(http.StatusCreated)
+		fmt.Fprint(writer, testAccountResponse)
+	}))
+	defer mockServer.Close()
+
+	ctx := WithIdempotencyKey(context.Background(), "outbox-record-42")
+	_, _, err := NewService(nil, mockServer.URL).CreateWithCtx(ctx, testSubmittedAccount())
+	assert.Nil(t, err)
+	assert.Equal(t, "outbox-record-42", <-received)
+}
+
+// Idempotencytest-3 - resolving a create tells apart a create that never landed, one that did, and an account that
+// belongs to somebody else
+func TestResolveCreate(t *testing.T) {
+	t.Parallel()
+	var body atomic.Value
+	body.Store("")
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "/"+testAccountID, req.URL.Path)
+		if body.Load().(string) == "" {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(writer, body.Load())
+	}))
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+
+	resolution, err := service.ResolveCreate(testSubmittedAccount())
+	assert.Nil(t, err)
+	assert.Nil(t, resolution.Account)
+	assert.False(t, resolution.Created())
+
+	body.Store(testAccountResponse)
+	resolution, err = service.ResolveCreate(testSubmittedAccount())
+	assert.Nil(t, err)
+	assert.True(t, resolution.Created())
+	assert.Equal(t, testAccountID, resolution.Account.Data.ID)
+
+	other := testSubmittedAccount()
+	other.Data.Attributes.BankID = "400302"
+	resolution, err = service.ResolveCreate(other)
+	assert.Nil(t, err)
+	assert.False(t, resolution.Created())
+	assert.Equal(t, []FieldDiff{{Field: "attributes.bank_id", Want: "400302", Got: "400300"}}, resolution.Diff)
+}
+
+// Idempotencytest-4 - an account without an ID can't be resolved, and a backend failure isn't an answer
+func TestResolveCreateErrors(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+
+	_, err := service.ResolveCreate(models.Account{})
+	assert.Equal(t, errNoAccountID, err)
+	resolution, err := service.ResolveCreate(testSubmittedAccount())
+	assert.Nil(t, resolution)
+	assert.NotNil(t, err)
+}
+
+// Idempotencytest-5 - the default retry policy doesn't resend a create, key or no key
+func TestCreateIsSentOnceByDefaultPolicy(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	c := client.NewDefaultClient()
+	policy := client.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	c.SetRetryPolicy(policy)
+	_, _, err := NewService(c, mockServer.URL).Create(testSubmittedAccount())
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,275 @@
+// Package memstore is the in-memory account store behind both fakes of the accounts api, accounts.FakeAccountsAPI and
+// accountstest.Server, so they keep, version, patch and filter accounts the same way
+package memstore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+var (
+	// no account with that id
+	ErrNotFound = errors.New("memstore: account not found")
+	// an account with that id already exists
+	ErrExists = errors.New("memstore: account already exists")
+	// the version doesn't match the stored one
+	ErrVersion = errors.New("memstore: version mismatch")
+	// the patched attributes don't decode into an account
+	ErrInvalidAttributes = errors.New("memstore: invalid account attributes")
+)
+
+// the list filters the store understands, and the attribute each of them matches on. customer_id isn't on the account
+// model, so that filter is ignored
+var filterAttributes = map[string]func(*models.AccountAttributes) string{
+	"bank_id":        func(a *models.AccountAttributes) string { return a.BankID },
+	"bank_id_code":   func(a *models.AccountAttributes) string { return a.BankIDCode },
+	"account_number": func(a *models.AccountAttributes) string { return a.AccountNumber },
+	"iban":           func(a *models.AccountAttributes) string { return a.Iban },
+	"country": func(a *models.AccountAttributes) string {
+		if a.Country == nil {
+			return ""
+		}
+		return *a.Country
+	},
+}
+
+// Store holds accounts in the order they were created. everything going in or out is deep copied, so the store and
+// its callers never share pointers. a zero Store is ready to use, and it is safe to share between goroutines
+type Store struct {
+	mu       sync.Mutex
+	accounts map[string]models.AccountData
+	// ids in the order they were created, which is the order they are listed in
+	order []string
+}
+
+// adds an account as is, filling in the server populated fields a create would, unless they are already set
+func (s *Store) Seed(data models.AccountData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(stamp(Copy(data)))
+}
+
+// stores a new account, with the server populated fields set afresh. ErrExists if the id is taken
+func (s *Store) Create(data models.AccountData) (models.AccountData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[data.ID]; ok {
+		return models.AccountData{}, ErrExists
+	}
+	data = Copy(data)
+	data.Version, data.CreatedOn, data.ModifiedOn = nil, nil, nil
+	data = stamp(data)
+	s.put(data)
+	return Copy(data), nil
+}
+
+// the account stored under id, if any
+func (s *Store) Get(id string) (models.AccountData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.accounts[id]
+	if !ok {
+		return models.AccountData{}, false
+	}
+	return Copy(data), true
+}
+
+// every account, in the order they were created
+func (s *Store) All() []models.AccountData {
+	return s.Find(nil)
+}
+
+// the accounts passing every filter[...] in query, in the order they were created
+func (s *Store) Find(query url.Values) []models.AccountData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := make([]models.AccountData, 0, len(s.order))
+	for _, id := range s.order {
+		if data := s.accounts[id]; Matches(data, query) {
+			found = append(found, Copy(data))
+		}
+	}
+	return found
+}
+
+// merges attributes into the stored ones, field by field, like a PATCH. version has to match the stored one, and is
+// bumped on success
+func (s *Store) Patch(id string, version int64, attributes map[string]json.RawMessage) (models.AccountData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.accounts[id]
+	if !ok {
+		return models.AccountData{}, ErrNotFound
+	}
+	if version != *data.Version {
+		return models.AccountData{}, ErrVersion
+	}
+
+	merged := make(map[string]json.RawMessage)
+	if data.Attributes != nil {
+		encoded, _ := json.Marshal(data.Attributes)
+		json.Unmarshal(encoded, &merged)
+	}
+	for key, value := range attributes {
+		merged[key] = value
+	}
+	encoded, _ := json.Marshal(merged)
+	patched := &models.AccountAttributes{}
+	if err := json.Unmarshal(encoded, patched); err != nil {
+		return models.AccountData{}, ErrInvalidAttributes
+	}
+
+	data = Copy(data)
+	next := *data.Version + 1
+	now := time.Now().UTC()
+	data.Attributes, data.Version, data.ModifiedOn = patched, &next, &now
+	s.put(data)
+	return Copy(data), nil
+}
+
+// removes an account. version has to match the stored one
+func (s *Store) Delete(id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.accounts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != *data.Version {
+		return ErrVersion
+	}
+	delete(s.accounts, id)
+	for i := range s.order {
+		if s.order[i] == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// forgets every account
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts, s.order = nil, nil
+}
+
+// stores an account, keeping the creation order. the lock must be held
+func (s *Store) put(data models.AccountData) {
+	if s.accounts == nil {
+		s.accounts = make(map[string]models.AccountData)
+	}
+	if _, ok := s.accounts[data.ID]; !ok {
+		s.order = append(s.order, data.ID)
+	}
+	s.accounts[data.ID] = data
+}
+
+// whether an account passes every filter[...] in query. a filter with more than one value matches any of them, and
+// filters the store doesn't understand are ignored
+func Matches(data models.AccountData, query url.Values) bool {
+	for key, values := range query {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		attribute, ok := filterAttributes[key[len("filter["):len(key)-1]]
+		if !ok {
+			continue
+		}
+		if data.Attributes == nil {
+			return false
+		}
+		found := false
+		for _, value := range values {
+			if attribute(data.Attributes) == value {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// the accounts on page number of a list paged by size. nil if the page is past the end
+func Page(accounts []models.AccountData, number int, size int) []models.AccountData {
+	start := number * size
+	if number < 0 || size < 1 || start >= len(accounts) {
+		return nil
+	}
+	end := start + size
+	if end > len(accounts) {
+		end = len(accounts)
+	}
+	return accounts[start:end]
+}
+
+// the json:api links of page number of a list of total accounts paged by size, relative to path. the filter[...]
+// entries of query are carried over into every link
+func Links(path string, query url.Values, number int, size int, total int) *models.Links {
+	last := 0
+	if total > 0 && size > 0 {
+		last = (total - 1) / size
+	}
+	links := &models.Links{
+		First: pageLink(path, query, 0, size),
+		Last:  pageLink(path, query, last, size),
+		Self:  pageLink(path, query, number, size),
+	}
+	if number < last {
+		links.Next = pageLink(path, query, number+1, size)
+	}
+	if number > 0 {
+		links.Prev = pageLink(path, query, number-1, size)
+	}
+	return links
+}
+
+// a relative link to a page of the list, keeping the filters of the query
+func pageLink(path string, query url.Values, number int, size int) string {
+	link := url.Values{}
+	for key, values := range query {
+		if strings.HasPrefix(key, "filter[") {
+			link[key] = values
+		}
+	}
+	link.Set("page[number]", strconv.Itoa(number))
+	link.Set("page[size]", strconv.Itoa(size))
+	return path + "?" + link.Encode()
+}
+
+// a deep copy of an account
+func Copy(data models.AccountData) models.AccountData {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	copied := models.AccountData{}
+	json.Unmarshal(encoded, &copied)
+	return copied
+}
+
+// fills in the server populated fields of a new account, unless they are already set
+func stamp(data models.AccountData) models.AccountData {
+	now := time.Now().UTC()
+	if data.Version == nil {
+		version := int64(0)
+		data.Version = &version
+	}
+	if data.CreatedOn == nil {
+		data.CreatedOn = &now
+	}
+	if data.ModifiedOn == nil {
+		data.ModifiedOn = &now
+	}
+	return data
+}
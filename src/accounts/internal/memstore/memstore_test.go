@@ -0,0 +1,120 @@
+package memstore
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that returns an account with the given id and bank id
+func newAccountData(id string, bankID string) models.AccountData {
+	return models.AccountData{ID: id, Attributes: &models.AccountAttributes{BankID: bankID}}
+}
+
+// memstore-test-1 - a created account is versioned and stamped, a duplicate is refused, and the caller's copy isn't
+// shared with the store
+func TestStoreCreate(t *testing.T) {
+	t.Parallel()
+	store := &Store{}
+	data := newAccountData("a", "400300")
+	version := int64(7)
+	data.Version = &version
+
+	created, err := store.Create(data)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), *created.Version, "the version is set by the store, not the caller")
+	assert.NotNil(t, created.CreatedOn)
+	_, err = store.Create(data)
+	assert.Equal(t, ErrExists, err)
+
+	created.Attributes.BankID = "changed"
+	stored, ok := store.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "400300", stored.Attributes.BankID)
+}
+
+// memstore-test-2 - a patch only changes the attributes it carries and bumps the version, which has to match
+func TestStorePatch(t *testing.T) {
+	t.Parallel()
+	store := &Store{}
+	store.Seed(newAccountData("a", "400300"))
+
+	patched, err := store.Patch("a", 0, map[string]json.RawMessage{"iban": json.RawMessage(`"GB33BUKB20201555555555"`)})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), *patched.Version)
+	assert.Equal(t, "400300", patched.Attributes.BankID)
+	assert.Equal(t, "GB33BUKB20201555555555", patched.Attributes.Iban)
+
+	_, err = store.Patch("a", 0, nil)
+	assert.Equal(t, ErrVersion, err)
+	_, err = store.Patch("b", 0, nil)
+	assert.Equal(t, ErrNotFound, err)
+	_, err = store.Patch("a", 1, map[string]json.RawMessage{"bank_id": json.RawMessage(`42`)})
+	assert.Equal(t, ErrInvalidAttributes, err)
+}
+
+// memstore-test-3 - a delete needs the current version, and takes the account out of the listing order
+func TestStoreDelete(t *testing.T) {
+	t.Parallel()
+	store := &Store{}
+	store.Seed(newAccountData("a", "400300"))
+	store.Seed(newAccountData("b", "400300"))
+
+	assert.Equal(t, ErrVersion, store.Delete("a", 1))
+	assert.Nil(t, store.Delete("a", 0))
+	assert.Equal(t, ErrNotFound, store.Delete("a", 0))
+	all := store.All()
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, "b", all[0].ID)
+	}
+
+	store.Reset()
+	assert.Empty(t, store.All())
+}
+
+// memstore-test-4 - filters match any of their values and skip the ones the store doesn't know, and pages are cut in
+// creation order
+func TestStoreFindAndPage(t *testing.T) {
+	t.Parallel()
+	store := &Store{}
+	for i, id := range []string{"a", "b", "c", "d"} {
+		bankID := "400300"
+		if i%2 == 1 {
+			bankID = "400302"
+		}
+		store.Seed(newAccountData(id, bankID))
+	}
+
+	found := store.Find(url.Values{"filter[bank_id]": {"400302"}, "filter[customer_id]": {"x"}})
+	if assert.Len(t, found, 2) {
+		assert.Equal(t, "b", found[0].ID)
+		assert.Equal(t, "d", found[1].ID)
+	}
+	assert.Len(t, store.Find(url.Values{"filter[bank_id]": {"400300", "400302"}}), 4)
+
+	page := Page(store.All(), 1, 3)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "d", page[0].ID)
+	}
+	assert.Nil(t, Page(store.All(), 2, 3))
+	assert.Nil(t, Page(store.All(), 0, 0))
+}
+
+// memstore-test-5 - the links point at the neighbouring pages, only where there are any, and keep the filters
+func TestLinks(t *testing.T) {
+	t.Parallel()
+	query := url.Values{"filter[country]": {"GB"}, "page[number]": {"1"}}
+	links := Links("/accounts", query, 1, 2, 5)
+	assert.Equal(t, "/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=0&page%5Bsize%5D=2", links.First)
+	assert.Equal(t, "/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=2&page%5Bsize%5D=2", links.Last)
+	assert.Equal(t, links.Last, links.Next)
+	assert.Equal(t, links.First, links.Prev)
+
+	links = Links("/accounts", nil, 0, 2, 0)
+	assert.Empty(t, links.Next)
+	assert.Empty(t, links.Prev)
+	assert.Equal(t, links.First, links.Last)
+}
@@ -0,0 +1,198 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// paging and filtering options for List. zero values are left out of the request, so the backend api defaults apply
+type ListOptions struct {
+	PageNumber int
+	PageSize   int
+	Filter     AccountFilter
+}
+
+// renders the paging options into page[number]/page[size] query params
+func (o ListOptions) queryParams() map[string]string {
+	query := make(map[string]string)
+	if o.PageNumber > 0 {
+		query["page[number]"] = strconv.Itoa(o.PageNumber)
+	}
+	if o.PageSize > 0 {
+		query["page[size]"] = strconv.Itoa(o.PageSize)
+	}
+	return query
+}
+
+// fetches a single page of accounts
+func (s *Service) ListPage(opts ListOptions) (*models.AccountList, *client.Response, error) {
+	return s.ListPageWithCtx(context.Background(), opts)
+}
+
+// fetches a single page of accounts with a custom context
+func (s *Service) ListPageWithCtx(ctx context.Context, opts ListOptions) (*models.AccountList, *client.Response, error) {
+	return s.fetchPage(ctx, client.Request{
+		Method:      http.MethodGet,
+		BaseURL:     s.BaseURL(),
+		QueryParams: opts.queryParams(),
+		QueryValues: opts.Filter.Values(),
+	})
+}
+
+// helper function that sends a list request and decodes the page that comes back
+func (s *Service) fetchPage(ctx context.Context, r client.Request) (*models.AccountList, *client.Response, error) {
+	resp, err := s.send(ctx, OperationList, r)
+	if err != nil {
+		return nil, resp, err
+	}
+	page := &models.AccountList{}
+	if err := json.Unmarshal([]byte(resp.Body), page); err != nil {
+		return nil, resp, fmt.Errorf("decoding account list from response: %w", err)
+	}
+	return page, resp, nil
+}
+
+// returns an iterator over every account, starting at the page described by opts and following links.next from there
+func (s *Service) List(opts ListOptions) *AccountIterator {
+	return s.ListWithCtx(context.Background(), opts)
+}
+
+// List with a custom context. the context is checked before every page is fetched
+func (s *Service) ListWithCtx(ctx context.Context, opts ListOptions) *AccountIterator {
+	return &AccountIterator{
+		service: s,
+		ctx:     ctx,
+		request: client.Request{
+			Method:      http.MethodGet,
+			BaseURL:     s.BaseURL(),
+			QueryParams: opts.queryParams(),
+			QueryValues: opts.Filter.Values(),
+		},
+		filter: opts.Filter.Values(),
+		index:  -1,
+	}
+}
+
+// AccountIterator walks through a paginated list of accounts, fetching pages lazily. the usual loop looks like:
+//
+//	it := accounts.List(accounts.ListOptions{PageSize: 100})
+//	for it.Next() {
+//		acc := it.Account()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// an iterator is not safe for concurrent use
+type AccountIterator struct {
+	service *Service
+	ctx     context.Context
+	// the request for the next page. an empty BaseURL means there are no pages left
+	request client.Request
+	// filter params to carry over to the next page, in case the backend leaves them out of links.next
+	filter url.Values
+	page   *models.AccountList
+	index  int
+	err    error
+	// an error working out the next page, only surfaced once the current page has been read
+	nextErr error
+}
+
+// advances to the next account, fetching the next page when the current one runs out. returns false once every
+// page has been read or an error occurred; check Err() to tell the two apart
+func (it *AccountIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if it.page != nil && it.index+1 < len(it.page.Data) {
+			it.index++
+			return true
+		}
+		if it.request.BaseURL == "" {
+			it.err = it.nextErr
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		page, _, err := it.service.fetchPage(it.ctx, it.request)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page
+		it.index = -1
+		next, err := it.nextRequest()
+
+		// an empty page, or a next link pointing back at the page we just read, means we're done
+		if len(page.Data) == 0 || next.BaseURL == it.request.BaseURL {
+			next = client.Request{}
+		}
+		it.request, it.nextErr = next, err
+	}
+}
+
+// works out the request for the page after the current one, from the links.next of the current page
+func (it *AccountIterator) nextRequest() (client.Request, error) {
+	if it.page.Links == nil || it.page.Links.Next == "" {
+		return client.Request{}, nil
+	}
+	current, err := url.Parse(it.request.BaseURL)
+	if err != nil {
+		return client.Request{}, err
+	}
+	next, err := current.Parse(it.page.Links.Next)
+	if err != nil {
+		return client.Request{}, fmt.Errorf("parsing next page link: %w", err)
+	}
+	query := next.Query()
+	for key, values := range it.filter {
+		if _, ok := query[key]; !ok {
+			query[key] = values
+		}
+	}
+	next.RawQuery = query.Encode()
+	return client.Request{
+		Method:  http.MethodGet,
+		BaseURL: next.String(),
+	}, nil
+}
+
+// the account the iterator is currently on. only valid after Next() returned true
+func (it *AccountIterator) Account() *models.Account {
+	if it.page == nil || it.index < 0 || it.index >= len(it.page.Data) {
+		return nil
+	}
+	return &models.Account{Data: &it.page.Data[it.index]}
+}
+
+// the first error the iterator ran into, if any
+func (it *AccountIterator) Err() error {
+	return it.err
+}
+
+// the links of the page the iterator is currently on
+func (it *AccountIterator) Links() *models.Links {
+	if it.page == nil {
+		return nil
+	}
+	return it.page.Links
+}
+
+// the meta object of the page the iterator is currently on
+func (it *AccountIterator) Meta() models.Meta {
+	if it.page == nil {
+		return nil
+	}
+	return it.page.Meta
+}
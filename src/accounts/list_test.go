@@ -0,0 +1,118 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that spins up a paginated list endpoint over `total` accounts. the handler mimics the form3 api by
+// returning a relative links.next until the last page
+func newPaginatedServer(t *testing.T, total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		number, _ := strconv.Atoi(req.URL.Query().Get("page[number]"))
+		size, err := strconv.Atoi(req.URL.Query().Get("page[size]"))
+		if err != nil || size == 0 {
+			size = 100
+		}
+
+		page := models.AccountList{Links: &models.Links{}, Meta: models.Meta{"page": float64(number)}}
+		for i := number * size; i < total && i < (number+1)*size; i++ {
+			page.Data = append(page.Data, models.AccountData{ID: fmt.Sprintf("account-%d", i), Type: "accounts"})
+		}
+		page.Links.Self = fmt.Sprintf("%s?page[number]=%d&page[size]=%d", req.URL.Path, number, size)
+		if (number+1)*size < total {
+			page.Links.Next = fmt.Sprintf("%s?page[number]=%d&page[size]=%d", req.URL.Path, number+1, size)
+		}
+		if err := json.NewEncoder(writer).Encode(page); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+// Listtest-1 - the iterator should follow links.next until every account has been read
+func TestListFollowsNextLinks(t *testing.T) {
+	t.Parallel()
+	mockServer := newPaginatedServer(t, 5)
+	defer mockServer.Close()
+
+	it := NewService(nil, mockServer.URL+"/v1/organisation/accounts").List(ListOptions{PageSize: 2})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Account().Data.ID)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"account-0", "account-1", "account-2", "account-3", "account-4"}, ids)
+	assert.Equal(t, "/v1/organisation/accounts?page[number]=2&page[size]=2", it.Links().Self)
+	assert.Equal(t, float64(2), it.Meta()["page"])
+	assert.False(t, it.Next(), "an exhausted iterator should stay exhausted")
+}
+
+// Listtest-2 - an empty list shouldn't yield anything, nor error
+func TestListEmpty(t *testing.T) {
+	t.Parallel()
+	mockServer := newPaginatedServer(t, 0)
+	defer mockServer.Close()
+
+	it := NewService(nil, mockServer.URL).List(ListOptions{})
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+	assert.Nil(t, it.Account())
+}
+
+// Listtest-3 - a single page is fetched with the page[number]/page[size] query params
+func TestListPage(t *testing.T) {
+	t.Parallel()
+	mockServer := newPaginatedServer(t, 5)
+	defer mockServer.Close()
+
+	page, resp, err := NewService(nil, mockServer.URL).ListPage(ListOptions{PageNumber: 1, PageSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, "account-2", page.Data[0].ID)
+	assert.Contains(t, page.Links.Next, "page[number]=2")
+}
+
+// Listtest-4 - cancelling the context between pages should stop the iterator
+func TestListStopsOnContextCancel(t *testing.T) {
+	t.Parallel()
+	mockServer := newPaginatedServer(t, 5)
+	defer mockServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := NewService(nil, mockServer.URL).ListWithCtx(ctx, ListOptions{PageSize: 2})
+	assert.True(t, it.Next())
+	assert.True(t, it.Next())
+	cancel()
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+}
+
+// Listtest-5 - an error response half way through should surface through Err()
+func TestListErrorMidway(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("page[number]") == "1" {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(writer, `{"data": [{"id": "account-0"}], "links": {"next": "?page[number]=1"}}`)
+	}))
+	defer mockServer.Close()
+
+	it := NewService(nil, mockServer.URL).List(ListOptions{})
+	assert.True(t, it.Next())
+	assert.Equal(t, "account-0", it.Account().Data.ID)
+	assert.False(t, it.Next())
+	assert.True(t, errors.Is(it.Err(), apierrors.ErrServerError))
+}
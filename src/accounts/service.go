@@ -0,0 +1,157 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// Service talks to one accounts api through its own client. a process can hold as many of these as it needs, e.g.
+// one per environment, without them stepping on each other
+type Service struct {
+	client *client.Client
+	url    *URL
+}
+
+// the service behind the package level Create/Fetch/Delete functions. it shares client.DefaultClient and DefaultUrl
+var DefaultService = &Service{
+	client: client.DefaultClient,
+	url:    &DefaultUrl,
+}
+
+// constructor method for a Service. a nil client gets a fresh client.NewDefaultClient(), and an empty baseURL falls
+// back to FORM3_ACCOUNTS_API_URL (or localhost if that isn't set either)
+func NewService(c *client.Client, baseURL string) *Service {
+	if c == nil {
+		c = client.NewDefaultClient()
+	}
+	return &Service{
+		client: c,
+		url:    &URL{baseURL: baseURL},
+	}
+}
+
+// returns the client this service sends its requests through
+func (s *Service) Client() *client.Client {
+	return s.client
+}
+
+// returns the base URL of the accounts resource this service talks to
+func (s *Service) BaseURL() string {
+	return s.url.GetDefaultBaseURL()
+}
+
+// the shapes of CreateWithCtx and FetchWithCtx, for the helpers that run on top of either a Service or a
+// FakeAccountsAPI
+type createFunc func(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error)
+type fetchFunc func(ctx context.Context, id string) (*models.Account, *client.Response, error)
+
+// the operations requests are tagged with (see client.WithOperation), so client middlewares can tell them apart
+const (
+	OperationCreate = "create"
+	OperationFetch  = "fetch"
+	OperationDelete = "delete"
+	OperationList   = "list"
+	OperationUpdate = "update"
+)
+
+// helper function that sends a Request through the client, tagged with its operation, and turns any non-2xx response
+// into an *errors.APIError. the raw Response is handed back alongside the error so callers can still look at it
+func (s *Service) send(ctx context.Context, operation string, r client.Request) (*client.Response, error) {
+	resp, err := s.client.SendWithCtx(client.WithOperation(ctx, operation), r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, apierrors.NewAPIError(r.Method, r.BaseURL, resp.StatusCode, resp.Headers, []byte(resp.Body))
+	}
+	return resp, nil
+}
+
+// helper function that decodes the body of a successful response into an Account
+func decodeAccount(resp *client.Response) (*models.Account, error) {
+	acc := &models.Account{}
+	if err := json.Unmarshal([]byte(resp.Body), acc); err != nil {
+		return nil, fmt.Errorf("decoding account from response: %w", err)
+	}
+	return acc, nil
+}
+
+// CREATE account without custom user context
+func (s *Service) Create(acc models.Account) (*models.Account, *client.Response, error) {
+	return s.CreateWithCtx(context.Background(), acc)
+}
+
+// Create with custom context. the returned Account is what the backend stored, including the server populated fields.
+// every call sends a fresh Idempotency-Key, or the one set with WithIdempotencyKey(), which stays the same across
+// retries of the call so the backend can tell a resend from a second create. creates are only retried when the
+// client's RetryPolicy has RetryIdempotencyKeyed set
+func (s *Service) CreateWithCtx(ctx context.Context, acc models.Account) (*models.Account, *client.Response, error) {
+	accEncoded, err := json.Marshal(acc)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		key = uuid.New().String()
+	}
+	resp, err := s.send(ctx, OperationCreate, client.Request{
+		Method:  http.MethodPost,
+		BaseURL: s.BaseURL(),
+		Headers: map[string]string{client.IdempotencyKeyHeader: key},
+		Body:    accEncoded,
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+	created, err := decodeAccount(resp)
+	return created, resp, err
+}
+
+// fetch implementation
+func (s *Service) Fetch(id string) (*models.Account, *client.Response, error) {
+	return s.FetchWithCtx(context.Background(), id)
+}
+
+// fetch with context implementation
+func (s *Service) FetchWithCtx(ctx context.Context, id string) (*models.Account, *client.Response, error) {
+	resp, err := s.send(ctx, OperationFetch, client.Request{
+		Method:  http.MethodGet,
+		BaseURL: fmt.Sprintf("%s/%s", s.BaseURL(), id),
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+	fetched, err := decodeAccount(resp)
+	return fetched, resp, err
+}
+
+// delete implementation
+func (s *Service) Delete(id string, version int) error {
+	return s.DeleteWithCtx(context.Background(), id, version)
+}
+
+// delete with context implementation
+func (s *Service) DeleteWithCtx(ctx context.Context, id string, version int) error {
+	_, err := s.deleteVersion(ctx, id, version)
+	return err
+}
+
+// helper function behind the delete operations, which hands back the raw response as well
+func (s *Service) deleteVersion(ctx context.Context, id string, version int) (*client.Response, error) {
+	resp, err := s.send(ctx, OperationDelete, client.Request{
+		Method:  http.MethodDelete,
+		BaseURL: fmt.Sprintf("%s/%s", s.BaseURL(), id),
+		QueryParams: map[string]string{
+			"version": strconv.Itoa(version),
+		},
+	})
+	return resp, asVersionConflict(err)
+}
@@ -0,0 +1,190 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAccountID = "f773707e-769e-4ed6-9194-ab69ff639d39"
+
+// Servicetest-1 - non-2xx responses from the backend should come back as typed errors that still carry the raw response
+func TestNon2xxResponsesReturnAPIError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		status   int
+		body     string
+		expected apierrors.HttpErrorCode
+	}{
+		{http.StatusBadRequest, `{"error_message": "id in body must be of type uuid"}`, apierrors.ErrBadRequest},
+		{http.StatusNotFound, ``, apierrors.ErrNotFound},
+		{http.StatusConflict, `{"error_message": "Account cannot be created as it violates a duplicate constraint"}`, apierrors.ErrConflict},
+		{http.StatusInternalServerError, `{"error_message": "boom"}`, apierrors.ErrServerError},
+	}
+	for _, test := range tests {
+		mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			writer.Header().Set(apierrors.RequestIDHeader, "fake-request-id")
+			writer.WriteHeader(test.status)
+			fmt.Fprint(writer, test.body)
+		}))
+
+		acc, resp, err := NewService(nil, mockServer.URL).Fetch(testAccountID)
+		mockServer.Close()
+
+		assert.Nil(t, acc)
+		assert.NotNil(t, resp, "the raw response should be returned alongside the error")
+		assert.Equal(t, test.status, resp.StatusCode)
+		assert.True(t, errors.Is(err, test.expected), "expected %v, got %v", test.expected, err)
+
+		var apiErr *apierrors.APIError
+		if assert.True(t, errors.As(err, &apiErr)) {
+			assert.Equal(t, http.MethodGet, apiErr.Method)
+			assert.Equal(t, "fake-request-id", apiErr.RequestID)
+		}
+	}
+}
+
+// Servicetest-2 - 2xx responses should not produce an error
+func TestSuccessfulResponseHasNoError(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer mockServer.Close()
+
+	err := NewService(nil, mockServer.URL).Delete(testAccountID, 0)
+	assert.Nil(t, err)
+}
+
+// Servicetest-3 - two services in one process should each talk to their own environment through their own client
+func TestIndependentServices(t *testing.T) {
+	t.Parallel()
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			writer.Header().Set("X-Environment", name)
+			writer.Header().Set("X-User-Agent", req.Header.Get("User-Agent"))
+			fmt.Fprint(writer, `{"data": {}}`)
+		}))
+	}
+	staging := newServer("staging")
+	defer staging.Close()
+	production := newServer("production")
+	defer production.Close()
+
+	stagingClient := client.NewDefaultClient()
+	stagingClient.Use(client.UserAgentMiddleware("staging-agent"))
+
+	stagingService := NewService(stagingClient, staging.URL)
+	productionService := NewService(nil, production.URL)
+
+	_, resp, err := stagingService.Fetch(testAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, "staging", resp.Headers.Get("X-Environment"))
+	assert.Equal(t, "staging-agent", resp.Headers.Get("X-User-Agent"))
+
+	_, resp, err = productionService.Fetch(testAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, "production", resp.Headers.Get("X-Environment"))
+	assert.NotEqual(t, "staging-agent", resp.Headers.Get("X-User-Agent"))
+	assert.Same(t, stagingClient, stagingService.Client())
+}
+
+// a canonical response from the fake form3 api, including the fields the server fills in
+const testAccountResponse = `{
+	"data": {
+		"attributes": {
+			"account_classification": "Personal",
+			"alternative_names": ["Sam Holder"],
+			"bank_id": "400300",
+			"bank_id_code": "GBDSC",
+			"base_currency": "GBP",
+			"bic": "NWBKGB22",
+			"country": "GB",
+			"name": ["Samantha Holder"]
+		},
+		"created_on": "2022-02-13T23:02:14.123Z",
+		"id": "f773707e-769e-4ed6-9194-ab69ff639d39",
+		"modified_on": "2022-02-13T23:02:14.123Z",
+		"organisation_id": "4fd712d9-e281-4add-8d66-800f6960b57c",
+		"type": "accounts",
+		"version": 0
+	},
+	"links": {
+		"self": "/v1/organisation/accounts/f773707e-769e-4ed6-9194-ab69ff639d39"
+	}
+}`
+
+// Servicetest-4 - create and fetch should hand back a decoded Account, server populated fields included
+func TestCreateAndFetchDecodeAccount(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			writer.WriteHeader(http.StatusCreated)
+		}
+		fmt.Fprint(writer, testAccountResponse)
+	}))
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+
+	created, resp, err := service.Create(models.Account{Data: &models.AccountData{ID: testAccountID}})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, testAccountID, created.Data.ID)
+	assert.Equal(t, int64(0), *created.Data.Version)
+	assert.Equal(t, 2022, created.Data.CreatedOn.Year())
+	assert.NotNil(t, created.Data.ModifiedOn)
+	assert.Equal(t, "Personal", *created.Data.Attributes.AccountClassification)
+	assert.Equal(t, "/v1/organisation/accounts/"+testAccountID, created.Links.Self)
+
+	fetched, resp, err := service.Fetch(testAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, created, fetched)
+}
+
+// Servicetest-5 - a 2xx response that isn't an account should be reported rather than silently ignored
+func TestFetchUndecodableBody(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(writer, "not json")
+	}))
+	defer mockServer.Close()
+
+	acc, resp, err := NewService(nil, mockServer.URL).Fetch(testAccountID)
+	assert.Nil(t, acc)
+	assert.NotNil(t, resp)
+	assert.NotNil(t, err)
+}
+
+// Servicetest-6 - every request should be tagged with its operation, so client middlewares can scope by it
+func TestRequestsAreTaggedWithOperation(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(writer, `{"data": {}}`)
+	}))
+	defer mockServer.Close()
+
+	var operations []string
+	c := client.NewDefaultClient()
+	c.Use(func(next client.Doer) client.Doer {
+		return client.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			operations = append(operations, client.OperationFromContext(req.Context()))
+			return next.Do(req)
+		})
+	})
+	service := NewService(c, mockServer.URL)
+
+	service.Create(models.Account{})
+	service.Fetch(testAccountID)
+	service.Update(testAccountID, 0, models.AccountAttributes{})
+	service.Delete(testAccountID, 0)
+	service.ListPage(ListOptions{})
+	assert.Equal(t, []string{OperationCreate, OperationFetch, OperationUpdate, OperationDelete, OperationList}, operations)
+}
@@ -0,0 +1,57 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+)
+
+// PATCH an account without custom user context. see UpdateWithCtx
+func (s *Service) Update(id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error) {
+	return s.UpdateWithCtx(context.Background(), id, version, patch)
+}
+
+// PATCH an account with custom context. only the non-empty fields of patch are sent. version must be the version the
+// caller last saw; if somebody else modified the account in the meantime the backend answers 409 and an
+// errors.ErrVersionConflict comes back, so the caller can fetch the account again and retry
+func (s *Service) UpdateWithCtx(ctx context.Context, id string, version int, patch models.AccountAttributes) (*models.Account, *client.Response, error) {
+	accountVersion := int64(version)
+	accEncoded, err := json.Marshal(models.Account{
+		Data: &models.AccountData{
+			Attributes: &patch,
+			ID:         id,
+			Type:       "accounts",
+			Version:    &accountVersion,
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.send(ctx, OperationUpdate, client.Request{
+		Method:  http.MethodPatch,
+		BaseURL: fmt.Sprintf("%s/%s", s.BaseURL(), id),
+		Body:    accEncoded,
+	})
+	if err != nil {
+		return nil, resp, asVersionConflict(err)
+	}
+	updated, err := decodeAccount(resp)
+	return updated, resp, err
+}
+
+// on an operation that carries a version, a 409 means the version was stale. this narrows ErrConflict down to
+// ErrVersionConflict so callers can tell it apart from a duplicate on create
+func asVersionConflict(err error) error {
+	var apiErr *apierrors.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == apierrors.ErrConflict {
+		apiErr.Code = apierrors.ErrVersionConflict
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
+	"github.com/sarabrajsingh/interview-accountapi/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// Updatetest-1 - update should PATCH the attributes along with the version, and hand back the updated account
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	received := make(chan models.Account, 1)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPatch, req.Method)
+		assert.Equal(t, "/"+testAccountID, req.URL.Path)
+		body, _ := ioutil.ReadAll(req.Body)
+		acc := models.Account{}
+		assert.Nil(t, json.Unmarshal(body, &acc))
+		received <- acc
+		fmt.Fprintf(writer, `{"data": {"id": %q, "version": 1, "attributes": {"bank_id": %q}}}`, testAccountID, acc.Data.Attributes.BankID)
+	}))
+	defer mockServer.Close()
+
+	updated, resp, err := NewService(nil, mockServer.URL).Update(testAccountID, 0, models.AccountAttributes{BankID: "400302"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(1), *updated.Data.Version)
+	assert.Equal(t, "400302", updated.Data.Attributes.BankID)
+
+	sent := <-received
+	assert.Equal(t, testAccountID, sent.Data.ID)
+	assert.Equal(t, "accounts", sent.Data.Type)
+	assert.Equal(t, int64(0), *sent.Data.Version)
+}
+
+// Updatetest-2 - a 409 on update is a version conflict, which is still a conflict
+func TestUpdateVersionConflict(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusConflict)
+		fmt.Fprint(writer, `{"error_message": "invalid version"}`)
+	}))
+	defer mockServer.Close()
+	service := NewService(nil, mockServer.URL)
+
+	updated, resp, err := service.Update(testAccountID, 3, models.AccountAttributes{BankID: "400302"})
+	assert.Nil(t, updated)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict))
+	assert.True(t, errors.Is(err, apierrors.ErrConflict))
+
+	err = service.Delete(testAccountID, 3)
+	assert.True(t, errors.Is(err, apierrors.ErrVersionConflict), "a 409 on delete is a version conflict too")
+}
+
+// Updatetest-3 - a 409 on create is a duplicate, not a version conflict
+func TestCreateConflictIsNotAVersionConflict(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusConflict)
+	}))
+	defer mockServer.Close()
+
+	_, _, err := NewService(nil, mockServer.URL).Create(models.Account{Data: &models.AccountData{ID: testAccountID}})
+	assert.True(t, errors.Is(err, apierrors.ErrConflict))
+	assert.False(t, errors.Is(err, apierrors.ErrVersionConflict))
+}
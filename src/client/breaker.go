@@ -0,0 +1,264 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// returned straight away, without touching the network, while a circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState int
+
+const (
+	StateClosed = CircuitState(iota)
+	StateOpen
+	StateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	default:
+		return "half-open"
+	}
+}
+
+// the rolling window is split into this many buckets, which expire one at a time
+const breakerBuckets = 10
+
+// settings for a CircuitBreaker. zero values get sensible defaults, see NewCircuitBreaker
+type CircuitBreakerSettings struct {
+	// share of failed requests within Window that trips the breaker. defaults to 0.5
+	FailureRatio float64
+	// the ratio is only looked at once Window holds at least this many requests. defaults to 10
+	MinRequests int
+	// how far back outcomes are counted. defaults to 30 seconds, and anything shorter than 10ms is rounded up to it
+	Window time.Duration
+	// how long the breaker stays open before letting probe requests through. defaults to 10 seconds
+	CoolDown time.Duration
+	// how many probe requests are let through while half-open. all of them have to succeed for the breaker to close,
+	// and a single failure opens it again. defaults to 1
+	HalfOpenProbes int
+	// decides if an outcome counts as a failure. defaults to transport errors and 5xx responses
+	IsFailure func(resp *http.Response, err error) bool
+	// called after every state change, outside of the breaker's lock. handy for alerting
+	OnStateChange func(from CircuitState, to CircuitState)
+}
+
+// a slice of the rolling window
+type breakerBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// CircuitBreaker stops sending requests to a backend that keeps failing, so callers fail fast with ErrCircuitOpen
+// instead of each waiting out the full timeout. after CoolDown a few probe requests are let through to see if the
+// backend has recovered
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+
+	mu       sync.Mutex
+	state    CircuitState
+	openedAt time.Time
+	buckets  [breakerBuckets]breakerBucket
+	// probes in flight and probes succeeded while half-open
+	probes    int
+	successes int
+	// bumped on every state change, so outcomes of requests let through under an older state can be told apart
+	generation uint64
+}
+
+// the shortest window the breaker accepts, so every bucket is at least a millisecond wide
+const minBreakerWindow = breakerBuckets * time.Millisecond
+
+// constructor method for a CircuitBreaker, filling in defaults for any zero settings
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureRatio <= 0 {
+		settings.FailureRatio = 0.5
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = 10
+	}
+	if settings.Window <= 0 {
+		settings.Window = 30 * time.Second
+	} else if settings.Window < minBreakerWindow {
+		settings.Window = minBreakerWindow
+	}
+	if settings.CoolDown <= 0 {
+		settings.CoolDown = 10 * time.Second
+	}
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = func(resp *http.Response, err error) bool {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+	return &CircuitBreaker{settings: settings}
+}
+
+// the current state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.settings.CoolDown {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// asks the breaker if a request may go through. a nil error has to be followed by exactly one call to record() or
+// release(), passing on the generation the request was let through under
+func (b *CircuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	var changed func()
+	defer func() {
+		b.mu.Unlock()
+		if changed != nil {
+			changed()
+		}
+	}()
+
+	if b.state == StateOpen {
+		if time.Since(b.openedAt) < b.settings.CoolDown {
+			return 0, ErrCircuitOpen
+		}
+		changed = b.setState(StateHalfOpen)
+	}
+	if b.state == StateHalfOpen {
+		if b.probes >= b.settings.HalfOpenProbes {
+			return 0, ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// records the outcome of a request that allow() let through. outcomes from an older generation are dropped, e.g. a
+// slow request let through while closed mustn't count as a probe once the breaker is half-open
+func (b *CircuitBreaker) record(generation uint64, failed bool) {
+	b.mu.Lock()
+	var changed func()
+	defer func() {
+		b.mu.Unlock()
+		if changed != nil {
+			changed()
+		}
+	}()
+
+	if generation != b.generation {
+		return
+	}
+	now := time.Now()
+	switch b.state {
+	case StateHalfOpen:
+		if failed {
+			changed = b.setState(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenProbes {
+			changed = b.setState(StateClosed)
+		}
+	case StateClosed:
+		bucket := b.bucket(now)
+		bucket.requests++
+		if failed {
+			bucket.failures++
+		}
+		requests, failures := b.totals(now)
+		if requests >= b.settings.MinRequests && float64(failures)/float64(requests) >= b.settings.FailureRatio {
+			changed = b.setState(StateOpen)
+		}
+	}
+}
+
+// hands back the outcome slot of a request that allow() let through but that never completed, e.g. because the
+// caller gave up on it. it counts neither way
+func (b *CircuitBreaker) release(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation == b.generation && b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// moves the breaker to a new state, resetting the counters, and returns the callback to run once the lock is released
+func (b *CircuitBreaker) setState(to CircuitState) func() {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.probes, b.successes = 0, 0
+	b.buckets = [breakerBuckets]breakerBucket{}
+	if to == StateOpen {
+		b.openedAt = time.Now()
+	}
+	if b.settings.OnStateChange == nil || from == to {
+		return nil
+	}
+	return func() { b.settings.OnStateChange(from, to) }
+}
+
+// the bucket for the current slice of the window, recycling it if it belonged to an older slice
+func (b *CircuitBreaker) bucket(now time.Time) *breakerBucket {
+	width := b.settings.Window / breakerBuckets
+	start := now.Truncate(width)
+	bucket := &b.buckets[(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	return bucket
+}
+
+// requests and failures across the buckets that are still inside the window
+func (b *CircuitBreaker) totals(now time.Time) (int, int) {
+	requests, failures := 0, 0
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < b.settings.Window {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+	return requests, failures
+}
+
+// built-in middleware that guards every request with a circuit breaker
+func CircuitBreakerMiddleware(b *CircuitBreaker) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			generation, err := b.allow()
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next.Do(req)
+			// the caller giving up says nothing about the health of the backend
+			if req.Context().Err() != nil {
+				b.release(generation)
+				return resp, err
+			}
+			b.record(generation, b.settings.IsFailure(resp, err))
+			return resp, err
+		})
+	}
+}
+
+// guards the default client with a new circuit breaker, and returns it so its state can be inspected
+func SetCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	return DefaultClient.SetCircuitBreaker(settings)
+}
+
+// guards a client struct with a new circuit breaker, and returns it so its state can be inspected. this registers a
+// CircuitBreakerMiddleware, so it should be called once, before the client is shared between goroutines
+func (c *Client) SetCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	breaker := NewCircuitBreaker(settings)
+	c.Use(CircuitBreakerMiddleware(breaker))
+	return breaker
+}
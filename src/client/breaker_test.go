@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helper that records state changes of a breaker
+type transitions struct {
+	mu      sync.Mutex
+	changes []string
+}
+
+func (tr *transitions) record(from CircuitState, to CircuitState) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.changes = append(tr.changes, from.String()+"->"+to.String())
+}
+
+func (tr *transitions) get() []string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return append([]string(nil), tr.changes...)
+}
+
+// breaker-test-1 - a failing backend trips the breaker, which then fails fast until the cool-down is over. a
+// successful probe closes it again
+func TestCircuitBreakerLifecycle(t *testing.T) {
+	t.Parallel()
+	var healthy int32
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer mockServer.Close()
+
+	tr := &transitions{}
+	c := NewDefaultClient()
+	breaker := c.SetCircuitBreaker(CircuitBreakerSettings{
+		FailureRatio:  0.5,
+		MinRequests:   4,
+		Window:        time.Minute,
+		CoolDown:      50 * time.Millisecond,
+		OnStateChange: tr.record,
+	})
+	req := Request{Method: http.MethodGet, BaseURL: mockServer.URL}
+
+	for i := 0; i < 4; i++ {
+		resp, err := c.Send(req)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	assert.Equal(t, StateOpen, breaker.State())
+
+	_, err := c.Send(req)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "an open breaker shouldn't reach the backend")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	atomic.StoreInt32(&healthy, 1)
+	resp, err := c.Send(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, StateClosed, breaker.State())
+
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, tr.get())
+}
+
+// breaker-test-2 - a failed probe sends the breaker straight back to open
+func TestCircuitBreakerFailedProbe(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{MinRequests: 1, CoolDown: 10 * time.Millisecond})
+	generation, err := breaker.allow()
+	assert.Nil(t, err)
+	breaker.record(generation, true)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	time.Sleep(15 * time.Millisecond)
+	generation, err = breaker.allow()
+	assert.Nil(t, err, "the first probe should be let through")
+	_, err = breaker.allow()
+	assert.Equal(t, ErrCircuitOpen, err, "only one probe at a time by default")
+	breaker.record(generation, true)
+	assert.Equal(t, StateOpen, breaker.State())
+	_, err = breaker.allow()
+	assert.Equal(t, ErrCircuitOpen, err)
+}
+
+// breaker-test-3 - the breaker only trips on the ratio once there are enough requests in the window, and old
+// failures fall out of the window
+func TestCircuitBreakerRatioAndWindow(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{FailureRatio: 0.5, MinRequests: 4, Window: 100 * time.Millisecond})
+	outcome := func(failed bool) {
+		generation, err := breaker.allow()
+		assert.Nil(t, err)
+		breaker.record(generation, failed)
+	}
+	for i := 0; i < 3; i++ {
+		outcome(true)
+	}
+	assert.Equal(t, StateClosed, breaker.State(), "3 requests is below MinRequests")
+
+	time.Sleep(120 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		outcome(false)
+	}
+	outcome(true)
+	assert.Equal(t, StateClosed, breaker.State(), "1 failure out of 4 in the window is below the ratio")
+}
+
+// breaker-test-4 - a caller cancelling its own request isn't a backend failure
+func TestCircuitBreakerIgnoresCallerCancellation(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer mockServer.Close()
+
+	c := NewDefaultClient()
+	breaker := c.SetCircuitBreaker(CircuitBreakerSettings{MinRequests: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err := c.SendWithCtx(ctx, Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.NotNil(t, err)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+// breaker-test-5 - the retry policy shouldn't retry a request the breaker refused
+func TestRetryDoesNotRetryOpenCircuit(t *testing.T) {
+	t.Parallel()
+	assert.False(t, DefaultRetryPolicy().shouldRetry(nil, ErrCircuitOpen))
+}
+
+// breaker-test-6 - a window too short to split into buckets is rounded up rather than dividing by zero
+func TestCircuitBreakerTinyWindow(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{MinRequests: 1, Window: 5 * time.Nanosecond})
+	assert.Equal(t, minBreakerWindow, breaker.settings.Window)
+	generation, err := breaker.allow()
+	assert.Nil(t, err)
+	assert.NotPanics(t, func() { breaker.record(generation, true) })
+	assert.Equal(t, StateOpen, breaker.State())
+}
+
+// breaker-test-7 - a slow request let through while closed, finishing after the breaker went half-open, isn't a
+// probe and can't close the breaker
+func TestCircuitBreakerIgnoresStaleOutcomes(t *testing.T) {
+	t.Parallel()
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{MinRequests: 1, CoolDown: 10 * time.Millisecond})
+	slow, err := breaker.allow()
+	assert.Nil(t, err)
+	failing, err := breaker.allow()
+	assert.Nil(t, err)
+	breaker.record(failing, true)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	time.Sleep(15 * time.Millisecond)
+	probe, err := breaker.allow()
+	assert.Nil(t, err)
+	breaker.record(slow, false)
+	breaker.release(slow)
+	assert.Equal(t, StateHalfOpen, breaker.State(), "the slow request's outcome should have been dropped")
+	_, err = breaker.allow()
+	assert.Equal(t, ErrCircuitOpen, err, "the probe slot should still be taken")
+
+	breaker.record(probe, false)
+	assert.Equal(t, StateClosed, breaker.State())
+}
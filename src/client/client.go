@@ -28,18 +28,31 @@ var DefaultClient = &Client{
 
 // constructor method for our custom client
 func NewDefaultClient() *Client {
-	return &Client{
-		HTTPClient: &http.Client{
-			Timeout: time.Duration(10) * time.Second,
-			Transport: &http.Transport{
-				DialContext: (&net.Dialer{
-					Timeout:   10 * time.Second,
-					KeepAlive: 10 * time.Second,
-				}).DialContext,
-				MaxIdleConns:        100,
-				MaxConnsPerHost:     100,
-				MaxIdleConnsPerHost: 100,
-			},
+	return &Client{HTTPClient: newHTTPClient()}
+}
+
+// constructor method for a client that sends its requests with any Doer, rather than a stl http client of its own.
+// it still gets a default stl http client in HTTPClient, which the timeout, transport and tls helpers tune and which
+// takes over if the Doer is unset with SetDoer(nil)
+func NewClient(d Doer) *Client {
+	if httpClient, ok := d.(*http.Client); ok {
+		return &Client{HTTPClient: httpClient}
+	}
+	return &Client{HTTPClient: newHTTPClient(), Doer: d}
+}
+
+// helper function that returns a stl http client with our default timeouts and connection pool
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Duration(10) * time.Second,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 10 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        100,
+			MaxConnsPerHost:     100,
+			MaxIdleConnsPerHost: 100,
 		},
 	}
 }
@@ -49,6 +62,9 @@ type Request struct {
 	BaseURL     string
 	Headers     map[string]string
 	QueryParams map[string]string
+	// query params that need more than one value per key, e.g. filter[country]=GB&filter[country]=FR. merged with
+	// QueryParams when the request is built
+	QueryValues url.Values
 	Body        []byte
 }
 
@@ -60,9 +76,16 @@ type Response struct {
 
 // struct around the main http engine. if a programmer needs to override any other environmental parameter for the client
 // they can leverage this struct to do so
-// TO-DO: do we need a wrapper around this struct if we're not coupling anything else to the stl *http.Client?
 type Client struct {
+	// the stl http client requests are sent with, unless Doer is set. the timeout, transport and tls helpers tune this one
 	HTTPClient *http.Client
+	// optional executor that requests are sent with instead of HTTPClient, e.g. a stub in unit tests or a http client
+	// wrapped by another library. see NewClient()
+	Doer Doer
+	// optional retry policy; nil means a Request is only ever sent once
+	Retry *RetryPolicy
+	// middlewares wrapped around HTTPClient (or Doer), outermost first. see Use()
+	Middlewares []Middleware
 }
 
 // helper functions to set http client timeouts
@@ -71,7 +94,7 @@ func SetTimeout(timeout int) {
 }
 
 func (c *Client) SetTimeout(timeout int) {
-	c.HTTPClient.Timeout = (time.Duration(timeout) * time.Second)
+	c.httpClient().Timeout = (time.Duration(timeout) * time.Second)
 }
 
 // helper functions to set http client Transport options
@@ -81,27 +104,59 @@ func SetClientTransportOpts(t *http.Transport) {
 
 // helper function to set http client Transport options on a client struct
 func (c *Client) SetClientTransportOpts(t *http.Transport) {
-	c.HTTPClient.Transport = t
+	c.httpClient().Transport = t
+}
+
+// shared stl http client that sends the requests of a client without one, e.g. a Client{} built by hand. the request
+// path only ever reads it, so such a client is safe to share between goroutines
+var fallbackHTTPClient = newHTTPClient()
+
+// the client's stl http client, giving it a default one of its own first if it has none. only for the setter helpers,
+// which like the rest of the setup shouldn't run while the client is in use
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		c.HTTPClient = newHTTPClient()
+	}
+	return c.HTTPClient
 }
 
 func (c *Client) SetClient(client *http.Client) {
 	c.HTTPClient = client
 }
 
+// helper function to send the default client's requests with any Doer
+func SetDoer(d Doer) {
+	DefaultClient.SetDoer(d)
+}
+
+// helper function to send a client struct's requests with any Doer. nil goes back to HTTPClient
+func (c *Client) SetDoer(d Doer) {
+	c.Doer = d
+}
+
 // helper function that generates URL encoded query params to a http request
-func generateQueryParams(baseURL string, query map[string]string) string {
-	baseURL += "?"
+func generateQueryParams(baseURL string, query url.Values) string {
+	return baseURL + "?" + query.Encode()
+}
+
+// merges the single and multi valued query params of a Request into one set of url.Values
+func (r Request) queryValues() url.Values {
 	parameters := url.Values{}
-	for key, value := range query {
+	for key, value := range r.QueryParams {
 		parameters.Add(key, value)
 	}
-	return baseURL + parameters.Encode()
+	for key, values := range r.QueryValues {
+		for _, value := range values {
+			parameters.Add(key, value)
+		}
+	}
+	return parameters
 }
 
 // transforms our custom Request struct to a http.Request object that can be consumed by the http client
 func buildRequest(r Request) (*http.Request, error) {
-	if len(r.QueryParams) != 0 {
-		r.BaseURL = generateQueryParams(r.BaseURL, r.QueryParams)
+	if query := r.queryValues(); len(query) != 0 {
+		r.BaseURL = generateQueryParams(r.BaseURL, query)
 	}
 
 	// generate our http client compatible http.Request object. canonical pattern to send HTTP requests to a http client
@@ -129,12 +184,13 @@ func buildRequest(r Request) (*http.Request, error) {
 // public facing method that takes a http.Request object, marshals it to the default client in this module,
 // and returns a raw http.Response
 func ExecuteRequest(r *http.Request) (*http.Response, error) {
-	return DefaultClient.HTTPClient.Do(r)
+	return DefaultClient.ExecuteRequest(r)
 }
 
-// public facing handler to the Client struct that emulates the function above
+// public facing handler to the Client struct that emulates the function above. the request goes through the
+// middleware chain before it reaches the http client
 func (c *Client) ExecuteRequest(r *http.Request) (*http.Response, error) {
-	return c.HTTPClient.Do(r)
+	return c.chain().Do(r)
 }
 
 // internal function that transforms a raw http.Response object from the http client to our consumable and custom defined Response object
@@ -173,16 +229,34 @@ func (c *Client) Send(r Request) (*Response, error) {
 	return c.sendWithCtx(context.Background(), r)
 }
 
-// this function allows the caller to override the context that gets passed to the http client. called by SendWithCtx
+// client send functionality with a caller supplied context
+func (c *Client) SendWithCtx(ctx context.Context, r Request) (*Response, error) {
+	return c.sendWithCtx(ctx, r)
+}
+
+// this function allows the caller to override the context that gets passed to the http client. called by SendWithCtx.
+// if the client has a retry policy, failed attempts are retried until the policy or the context gives up
 func (c *Client) sendWithCtx(ctx context.Context, r Request) (*Response, error) {
-	request, err := buildRequest(r)
-	if err != nil {
-		return nil, err
-	}
-	request = request.WithContext(ctx)
-	result, err := c.ExecuteRequest(request)
-	if err != nil {
-		return nil, err
+	attempts := c.Retry.attemptsFor(r)
+	for attempt := 1; ; attempt++ {
+		// the request is rebuilt on every attempt so the body reader is rewound to the start
+		request, err := buildRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		request = request.WithContext(withAttempt(ctx, attempt))
+
+		var response *Response
+		result, err := c.ExecuteRequest(request)
+		if err == nil {
+			response, err = buildResponse(result)
+		}
+
+		if attempt >= attempts || ctx.Err() != nil || !c.Retry.shouldRetry(response, err) {
+			return response, err
+		}
+		if err := sleepWithCtx(ctx, c.Retry.backoff(attempt, response)); err != nil {
+			return nil, err
+		}
 	}
-	return buildResponse(result)
 }
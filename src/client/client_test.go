@@ -6,8 +6,10 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -123,15 +125,33 @@ func TestSend(t *testing.T) {
 // unit-test-3 - test our built-in URL query params helper function
 func TestGenerateQueryParams(t *testing.T) {
 	t.Parallel()
-	query := make(map[string]string)
+	query := url.Values{}
 	hostname := "http://superfake.com"
-	query["foo"] = "bar"
-	query["foofoo"] = "barbar"
+	query.Set("foo", "bar")
+	query.Set("foofoo", "barbar")
 	generated := generateQueryParams(hostname, query)
 	expected := "http://superfake.com?foo=bar&foofoo=barbar"
 	assert.Equal(t, generated, expected, "generated and expected should have equaled each other")
 }
 
+// unit-test-3b - multi valued query params are merged with the single valued ones when a request is built
+func TestBuildRequestMultiValueQueryParams(t *testing.T) {
+	t.Parallel()
+	req := Request{
+		Method:      http.MethodGet,
+		BaseURL:     "http://superfake.com",
+		QueryParams: map[string]string{"page[size]": "10"},
+		QueryValues: url.Values{"filter[country]": []string{"GB", "FR"}},
+	}
+
+	request, err := buildRequest(req)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, []string{"GB", "FR"}, request.URL.Query()["filter[country]"])
+	assert.Equal(t, "10", request.URL.Query().Get("page[size]"))
+}
+
 // unit-test-4 - test our buildRequest method which transforms our Request strcut to something consumable by the http client
 // in the form of a http.Request object
 func TestBuildGoodRequest(t *testing.T) {
@@ -312,3 +332,89 @@ func TestBuildRequestDefaultHeaders(t *testing.T) {
 	assert.Equal(t, request.Method, "GET", "buildRequest didn't set request method properly")
 	assert.Equal(t, request.Header, http.Header(http.Header{"Content-Type": []string{"application/json"}}), "failed to set proper headers")
 }
+
+// unit-test-14 - a client built on a Doer should send through it, middlewares included, and a client built on a stl
+// http client should use it as HTTPClient
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+	var seen []string
+	c := NewClient(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		seen = append(seen, "doer")
+		return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+	}))
+	c.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			seen = append(seen, "middleware")
+			return next.Do(req)
+		})
+	})
+	resp, err := c.Send(Request{Method: http.MethodGet, BaseURL: "http://superfake.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Equal(t, []string{"middleware", "doer"}, seen)
+
+	httpClient := &http.Client{}
+	c = NewClient(httpClient)
+	assert.Equal(t, httpClient, c.HTTPClient)
+	assert.Nil(t, c.Doer)
+	c.SetTimeout(5)
+	assert.Equal(t, 5*time.Second, httpClient.Timeout)
+}
+
+// unit-test-15 - a client built on a Doer still has a stl http client for the helpers to tune, which takes over once
+// the Doer is unset
+func TestNewClientKeepsHTTPClient(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer mockServer.Close()
+
+	c := NewClient(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+	}))
+	if assert.NotNil(t, c.HTTPClient) {
+		c.SetTimeout(5)
+		assert.Equal(t, 5*time.Second, c.HTTPClient.Timeout)
+		c.SetClientTransportOpts(&http.Transport{})
+		assert.Nil(t, c.SetTLSOptions(TLSOptions{}))
+	}
+
+	c.SetDoer(nil)
+	resp, err := c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	handBuilt := &Client{}
+	handBuilt.SetTimeout(5)
+	assert.Equal(t, 5*time.Second, handBuilt.HTTPClient.Timeout)
+	resp, err = (&Client{}).Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+// unit-test-16 - a hand built Client{} shared between goroutines sends through the shared fallback http client,
+// without touching its own fields. run with -race
+func TestZeroClientConcurrentSends(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer mockServer.Close()
+
+	c := &Client{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+			assert.Nil(t, err)
+			if resp != nil {
+				assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Nil(t, c.HTTPClient, "sending shouldn't have given the client a http client of its own")
+}
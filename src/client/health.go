@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// the status a health endpoint reports
+type HealthStatus string
+
+const (
+	HealthUp   = HealthStatus("up")
+	HealthDown = HealthStatus("down")
+)
+
+// the outcome of a health check
+type HealthCheck struct {
+	Status HealthStatus
+	// status code of the health endpoint's response
+	StatusCode int
+}
+
+// whether the api is serving
+func (h *HealthCheck) Ready() bool {
+	return h.Status == HealthUp
+}
+
+// checks the health endpoint at healthURL with the default client
+func Health(ctx context.Context, healthURL string) (*HealthCheck, error) {
+	return DefaultClient.Health(ctx, healthURL)
+}
+
+// checks the health endpoint at healthURL, which answers {"status": "up"} while the api is serving. a non-2xx
+// response is reported as HealthDown rather than as an error; the error is kept for when no answer came back at all
+func (c *Client) Health(ctx context.Context, healthURL string) (*HealthCheck, error) {
+	resp, err := c.SendWithCtx(WithOperation(ctx, "health"), Request{Method: http.MethodGet, BaseURL: healthURL})
+	if err != nil {
+		return nil, err
+	}
+
+	health := &HealthCheck{Status: HealthDown, StatusCode: resp.StatusCode}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return health, nil
+	}
+	// a 2xx without a status in the body still means the api is serving
+	var body struct {
+		Status HealthStatus `json:"status"`
+	}
+	health.Status = HealthUp
+	if json.Unmarshal([]byte(resp.Body), &body) == nil && body.Status != "" {
+		health.Status = body.Status
+	}
+	return health, nil
+}
+
+// blocks until the health endpoint at healthURL reports up, using the default client
+func WaitUntilReady(ctx context.Context, healthURL string, pollInterval time.Duration) error {
+	return DefaultClient.WaitUntilReady(ctx, healthURL, pollInterval)
+}
+
+// blocks until the health endpoint at healthURL reports up, or ctx is done. see PollUntilReady for how it polls
+func (c *Client) WaitUntilReady(ctx context.Context, healthURL string, pollInterval time.Duration) error {
+	return PollUntilReady(ctx, healthURL, pollInterval, func(ctx context.Context) (*HealthCheck, error) {
+		return c.Health(ctx, healthURL)
+	})
+}
+
+// blocks until check reports up, or ctx is done. the first poll goes out straight away, then the wait between polls
+// starts at pollInterval (a second if it isn't positive) and doubles up to ten times pollInterval, so a slow start
+// doesn't get hammered. when ctx is done the error wraps the context error, along with what the last poll of target
+// saw. handy for anything with a health check of its own, e.g. a fake of an api
+func PollUntilReady(ctx context.Context, target string, pollInterval time.Duration, check func(ctx context.Context) (*HealthCheck, error)) error {
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	wait := pollInterval
+	for {
+		health, err := check(ctx)
+		if err == nil && health.Ready() {
+			return nil
+		}
+
+		last := fmt.Sprint(err)
+		if err == nil {
+			last = fmt.Sprintf("status %q (%d)", health.Status, health.StatusCode)
+		}
+		if sleepErr := sleepWithCtx(ctx, wait); sleepErr != nil {
+			return fmt.Errorf("waiting for %s to be ready, last poll saw %s: %w", target, last, sleepErr)
+		}
+		if wait *= 2; wait > 10*pollInterval {
+			wait = 10 * pollInterval
+		}
+	}
+}
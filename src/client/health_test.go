@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// health-test-1 - the status in the body is reported as-is, and a non-2xx response is down rather than an error
+func TestHealth(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		status   int
+		body     string
+		expected HealthStatus
+	}{
+		{http.StatusOK, `{"status": "up"}`, HealthUp},
+		{http.StatusOK, `{"status": "down"}`, HealthDown},
+		{http.StatusOK, ``, HealthUp},
+		{http.StatusServiceUnavailable, `{"status": "up"}`, HealthDown},
+	}
+	for _, test := range tests {
+		mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+			writer.WriteHeader(test.status)
+			writer.Write([]byte(test.body))
+		}))
+		c := NewDefaultClient()
+		c.Use(func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "health", OperationFromContext(req.Context()))
+				return next.Do(req)
+			})
+		})
+		health, err := c.Health(context.Background(), mockServer.URL)
+		mockServer.Close()
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, health.Status)
+		assert.Equal(t, test.status, health.StatusCode)
+		assert.Equal(t, test.expected == HealthUp, health.Ready())
+	}
+
+	_, err := NewDefaultClient().Health(context.Background(), "http://127.0.0.1:1")
+	assert.NotNil(t, err, "no answer at all is an error")
+}
+
+// health-test-2 - waiting should keep polling until the api comes up
+func TestWaitUntilReady(t *testing.T) {
+	t.Parallel()
+	var polls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&polls, 1) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.Write([]byte(`{"status": "up"}`))
+	}))
+	defer mockServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	assert.Nil(t, NewDefaultClient().WaitUntilReady(ctx, mockServer.URL, 10*time.Millisecond))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(30*time.Millisecond), "the second wait should have doubled")
+}
+
+// health-test-3 - waiting gives up when the context does, saying what the last poll saw
+func TestWaitUntilReadyTimesOut(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := NewDefaultClient().WaitUntilReady(ctx, mockServer.URL, 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "503")
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Logger is the key/value logging interface the client logs through, e.g. Info("msg", "status", 200, "attempt", 1).
+// a *slog.Logger satisfies it as-is, and NewStdLogger adapts a stl *log.Logger
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// the value sensitive fields are replaced with
+const redacted = "[REDACTED]"
+
+// the models.AccountAttributes fields that identify a person or an account, and never make it into a log line
+var DefaultRedactedFields = []string{"account_number", "iban", "name", "alternative_names", "secondary_identification"}
+
+// options for LoggingMiddleware
+type LoggingOptions struct {
+	// logs the request and response bodies at debug level, with sensitive fields redacted
+	LogBodies bool
+	// json fields to redact on top of DefaultRedactedFields
+	RedactFields []string
+}
+
+// built-in middleware that logs one line per attempt with the method, url, status, latency, attempt number and
+// operation of the request. failures are logged at error level, 4xx responses at warn level and the rest at info level.
+// sensitive fields are redacted from bodies and from filter query params
+func LoggingMiddleware(l Logger, opts LoggingOptions) Middleware {
+	fields := redactionSet(opts.RedactFields)
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			args := []interface{}{
+				"method", req.Method,
+				"url", redactURL(req.URL, fields),
+				"attempt", AttemptFromContext(req.Context()),
+			}
+			if operation := OperationFromContext(req.Context()); operation != "" {
+				args = append(args, "operation", operation)
+			}
+			if opts.LogBodies {
+				if body, err := readBody(req); err == nil && len(body) > 0 {
+					l.Debug("http request body", append(args, "body", string(redactJSON(body, fields)))...)
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.Do(req)
+			args = append(args, "latency", time.Since(start))
+
+			if err != nil {
+				l.Error("http request failed", append(args, "error", err.Error())...)
+				return resp, err
+			}
+			args = append(args, "status", resp.StatusCode)
+			if opts.LogBodies {
+				body, readErr := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+				if readErr == nil && len(body) > 0 {
+					l.Debug("http response body", append(args, "body", string(redactJSON(body, fields)))...)
+				}
+			}
+
+			switch {
+			case resp.StatusCode >= http.StatusInternalServerError:
+				l.Error("http request", args...)
+			case resp.StatusCode >= http.StatusBadRequest:
+				l.Warn("http request", args...)
+			default:
+				l.Info("http request", args...)
+			}
+			return resp, err
+		})
+	}
+}
+
+// returns a copy of a json body with the value of every DefaultRedactedFields field, plus any extra fields, replaced
+// with [REDACTED] at any depth. a body that isn't json can't be inspected, so it is redacted as a whole
+func RedactJSON(body []byte, extra ...string) []byte {
+	return redactJSON(body, redactionSet(extra))
+}
+
+// the set of fields to redact, DefaultRedactedFields plus any extra ones
+func redactionSet(extra []string) map[string]bool {
+	fields := make(map[string]bool)
+	for _, field := range DefaultRedactedFields {
+		fields[field] = true
+	}
+	for _, field := range extra {
+		fields[field] = true
+	}
+	return fields
+}
+
+func redactJSON(body []byte, fields map[string]bool) []byte {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return []byte(redacted)
+	}
+	encoded, err := json.Marshal(redactValue(decoded, fields))
+	if err != nil {
+		return []byte(redacted)
+	}
+	return encoded
+}
+
+// walks a decoded json value, redacting sensitive fields
+func redactValue(value interface{}, fields map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if fields[key] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactValue(child, fields)
+		}
+	}
+	return value
+}
+
+// redacts query params that filter on a sensitive field, e.g. filter[iban]
+func redactURL(u *url.URL, fields map[string]bool) string {
+	query := u.Query()
+	if len(query) == 0 {
+		return u.String()
+	}
+	for key := range query {
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]")
+		if fields[name] {
+			query[key] = []string{redacted}
+		}
+	}
+	copied := *u
+	copied.RawQuery = query.Encode()
+	return copied.String()
+}
+
+// adapts a stl *log.Logger to the Logger interface, printing "LEVEL msg key=value key=value"
+func NewStdLogger(l *log.Logger) Logger {
+	return &stdLogger{l: l}
+}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s *stdLogger) Debug(msg string, args ...interface{}) { s.print("DEBUG", msg, args) }
+func (s *stdLogger) Info(msg string, args ...interface{})  { s.print("INFO", msg, args) }
+func (s *stdLogger) Warn(msg string, args ...interface{})  { s.print("WARN", msg, args) }
+func (s *stdLogger) Error(msg string, args ...interface{}) { s.print("ERROR", msg, args) }
+
+func (s *stdLogger) print(level string, msg string, args []interface{}) {
+	line := level + " " + msg
+	for i := 0; i+1 < len(args); i += 2 {
+		line += fmt.Sprintf(" %v=%v", args[i], args[i+1])
+	}
+	s.l.Println(line)
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helper logger that records every line it is handed
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []loggedLine
+}
+
+type loggedLine struct {
+	level string
+	msg   string
+	args  map[string]interface{}
+}
+
+func (r *recordingLogger) Debug(msg string, args ...interface{}) { r.record("debug", msg, args) }
+func (r *recordingLogger) Info(msg string, args ...interface{})  { r.record("info", msg, args) }
+func (r *recordingLogger) Warn(msg string, args ...interface{})  { r.record("warn", msg, args) }
+func (r *recordingLogger) Error(msg string, args ...interface{}) { r.record("error", msg, args) }
+
+func (r *recordingLogger) record(level string, msg string, args []interface{}) {
+	line := loggedLine{level: level, msg: msg, args: make(map[string]interface{})}
+	for i := 0; i+1 < len(args); i += 2 {
+		line.args[fmt.Sprint(args[i])] = args[i+1]
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, line)
+}
+
+func (r *recordingLogger) get() []loggedLine {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]loggedLine(nil), r.lines...)
+}
+
+const piiBody = `{"data":{"id":"1","attributes":{"bank_id":"400300","iban":"GB11NWBK40030041426819","account_number":"41426819",` +
+	`"name":["Samantha Holder"],"alternative_names":["Sam Holder"],"secondary_identification":"A1B2C3D4"}}}`
+
+// logging-test-1 - every attempt is logged with its method, url, status, latency, attempt and operation, at a level
+// that matches the outcome
+func TestLoggingMiddleware(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer mockServer.Close()
+
+	logger := &recordingLogger{}
+	c := NewDefaultClient()
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, RetryableStatusCodes: map[int]bool{http.StatusServiceUnavailable: true}, RetryableMethods: map[string]bool{http.MethodGet: true}})
+	c.Use(LoggingMiddleware(logger, LoggingOptions{}))
+	resp, err := c.SendWithCtx(WithOperation(context.Background(), "fetch"), Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := logger.get()
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "error", lines[0].level)
+	assert.Equal(t, http.StatusServiceUnavailable, lines[0].args["status"])
+	assert.Equal(t, 1, lines[0].args["attempt"])
+	assert.Equal(t, "info", lines[1].level)
+	assert.Equal(t, http.StatusOK, lines[1].args["status"])
+	assert.Equal(t, 2, lines[1].args["attempt"])
+	for _, line := range lines {
+		assert.Equal(t, http.MethodGet, line.args["method"])
+		assert.Equal(t, mockServer.URL, line.args["url"])
+		assert.Equal(t, "fetch", line.args["operation"])
+		assert.Contains(t, line.args, "latency")
+	}
+}
+
+// logging-test-2 - bodies are logged at debug level with account holder details redacted, and the response body is
+// still there for the caller
+func TestLoggingMiddlewareRedactsBodies(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.Write([]byte(piiBody))
+	}))
+	defer mockServer.Close()
+
+	logger := &recordingLogger{}
+	c := NewDefaultClient()
+	c.Use(LoggingMiddleware(logger, LoggingOptions{LogBodies: true, RedactFields: []string{"bank_id"}}))
+	resp, err := c.Send(Request{Method: http.MethodPost, BaseURL: mockServer.URL + "?filter[iban]=GB11NWBK40030041426819&filter[country]=GB", Body: []byte(piiBody)})
+	assert.Nil(t, err)
+	assert.Equal(t, piiBody, string(resp.Body))
+
+	var logged strings.Builder
+	for _, line := range logger.get() {
+		fmt.Fprintf(&logged, "%s %s %v\n", line.level, line.msg, line.args)
+	}
+	for _, secret := range []string{"GB11NWBK40030041426819", "41426819", "Samantha Holder", "Sam Holder", "A1B2C3D4", "400300"} {
+		assert.NotContains(t, logged.String(), secret)
+	}
+	assert.Contains(t, logged.String(), "debug http request body")
+	assert.Contains(t, logged.String(), "debug http response body")
+	assert.Contains(t, logged.String(), "filter%5Bcountry%5D=GB")
+}
+
+// logging-test-3 - redaction works at any depth, and a body that isn't json is redacted as a whole
+func TestRedactJSON(t *testing.T) {
+	t.Parallel()
+	assert.JSONEq(t, `{"data":[{"iban":"[REDACTED]","name":"[REDACTED]","country":"GB"}]}`,
+		string(RedactJSON([]byte(`{"data":[{"iban":"GB11","name":["Sam"],"country":"GB"}]}`))))
+	assert.JSONEq(t, `{"bic":"[REDACTED]"}`, string(RedactJSON([]byte(`{"bic":"NWBKGB22"}`), "bic")))
+	assert.Equal(t, "[REDACTED]", string(RedactJSON([]byte("iban=GB11"))))
+}
+
+// logging-test-4 - the stl adapter prints the level, message and key/value pairs on one line
+func TestStdLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	NewStdLogger(log.New(&buf, "", 0)).Warn("http request", "status", 404, "attempt", 1)
+	assert.Equal(t, "WARN http request status=404 attempt=1\n", buf.String())
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsCollector is handed one observation per attempt sent through a client. Metrics is the built-in
+// implementation, but a consumer already running a metrics library can plug in an adapter instead
+type MetricsCollector interface {
+	// statusClass is "2xx", "3xx", "4xx" or "5xx", or "error" when no response came back at all
+	ObserveRequest(operation string, method string, statusClass string, latency time.Duration)
+}
+
+// upper bounds, in seconds, of the latency histogram buckets when none are given to NewMetrics
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+const (
+	requestsMetric = "accountapi_client_requests_total"
+	durationMetric = "accountapi_client_request_duration_seconds"
+)
+
+// the labels every series is broken down by
+type metricLabels struct {
+	operation   string
+	method      string
+	statusClass string
+}
+
+type metricSeries struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// Metrics is an in-memory MetricsCollector that keeps a request counter and a latency histogram per operation,
+// method and status class. it is a http.Handler that serves them in the prometheus text exposition format, so it can
+// be mounted on a /metrics endpoint as-is
+type Metrics struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[metricLabels]*metricSeries
+}
+
+// constructor method for Metrics. buckets are the upper bounds of the latency histogram in seconds, and default to
+// DefaultLatencyBuckets
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Metrics{buckets: sorted, series: make(map[metricLabels]*metricSeries)}
+}
+
+// records a single attempt
+func (m *Metrics) ObserveRequest(operation string, method string, statusClass string, latency time.Duration) {
+	labels := metricLabels{operation: operation, method: method, statusClass: statusClass}
+	seconds := latency.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.series[labels]
+	if !ok {
+		s = &metricSeries{buckets: make([]uint64, len(m.buckets))}
+		m.series[labels] = s
+	}
+	s.count++
+	s.sum += seconds
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+// writes every series in the prometheus text exposition format, in a stable order
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	labels := make([]metricLabels, 0, len(m.series))
+	snapshot := make(map[metricLabels]metricSeries, len(m.series))
+	for l, s := range m.series {
+		labels = append(labels, l)
+		snapshot[l] = metricSeries{count: s.count, sum: s.sum, buckets: append([]uint64(nil), s.buckets...)}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if a.operation != b.operation {
+			return a.operation < b.operation
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.statusClass < b.statusClass
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# HELP %s Requests sent to the accounts API.\n", requestsMetric)
+	fmt.Fprintf(&buf, "# TYPE %s counter\n", requestsMetric)
+	for _, l := range labels {
+		fmt.Fprintf(&buf, "%s{%s} %d\n", requestsMetric, l.format(), snapshot[l].count)
+	}
+	fmt.Fprintf(&buf, "# HELP %s Latency of requests sent to the accounts API.\n", durationMetric)
+	fmt.Fprintf(&buf, "# TYPE %s histogram\n", durationMetric)
+	for _, l := range labels {
+		s := snapshot[l]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&buf, "%s_bucket{%s,le=\"%s\"} %d\n", durationMetric, l.format(), formatFloat(bound), s.buckets[i])
+		}
+		fmt.Fprintf(&buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", durationMetric, l.format(), s.count)
+		fmt.Fprintf(&buf, "%s_sum{%s} %s\n", durationMetric, l.format(), formatFloat(s.sum))
+		fmt.Fprintf(&buf, "%s_count{%s} %d\n", durationMetric, l.format(), s.count)
+	}
+	return buf.WriteTo(w)
+}
+
+// serves the metrics in the prometheus text exposition format
+func (m *Metrics) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(writer)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (l metricLabels) format() string {
+	return fmt.Sprintf(`operation="%s",method="%s",status_class="%s"`,
+		labelEscaper.Replace(l.operation), labelEscaper.Replace(l.method), labelEscaper.Replace(l.statusClass))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// buckets a status code into 2xx/3xx/4xx/5xx, keeping the number of series small
+func statusClass(resp *http.Response, err error) string {
+	if err != nil || resp == nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode/100) + "xx"
+}
+
+// built-in middleware that reports every attempt to a MetricsCollector, labelled by the operation the accounts
+// package tagged the request with ("unknown" for untagged requests)
+func MetricsMiddleware(collector MetricsCollector) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			operation := OperationFromContext(req.Context())
+			if operation == "" {
+				operation = "unknown"
+			}
+			start := time.Now()
+			resp, err := next.Do(req)
+			collector.ObserveRequest(operation, req.Method, statusClass(resp, err), time.Since(start))
+			return resp, err
+		})
+	}
+}
+
+// reports every request sent through the default client to a new Metrics, and returns it so it can be served
+func SetMetrics() *Metrics {
+	return DefaultClient.SetMetrics()
+}
+
+// reports every request sent through a client struct to a new Metrics, and returns it so it can be served. this
+// registers a MetricsMiddleware, so it should be called once, before the client is shared between goroutines
+func (c *Client) SetMetrics() *Metrics {
+	metrics := NewMetrics()
+	c.Use(MetricsMiddleware(metrics))
+	return metrics
+}
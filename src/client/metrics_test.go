@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// metrics-test-1 - observations should land in the right series and histogram buckets
+func TestMetricsObserveRequest(t *testing.T) {
+	t.Parallel()
+	metrics := NewMetrics(0.1, 0.01)
+	metrics.ObserveRequest("fetch", http.MethodGet, "2xx", 5*time.Millisecond)
+	metrics.ObserveRequest("fetch", http.MethodGet, "2xx", 50*time.Millisecond)
+	metrics.ObserveRequest("fetch", http.MethodGet, "2xx", time.Second)
+	metrics.ObserveRequest("create", http.MethodPost, "4xx", 5*time.Millisecond)
+
+	var out strings.Builder
+	_, err := metrics.WriteTo(&out)
+	assert.Nil(t, err)
+	expected := `# HELP accountapi_client_requests_total Requests sent to the accounts API.
+# TYPE accountapi_client_requests_total counter
+accountapi_client_requests_total{operation="create",method="POST",status_class="4xx"} 1
+accountapi_client_requests_total{operation="fetch",method="GET",status_class="2xx"} 3
+# HELP accountapi_client_request_duration_seconds Latency of requests sent to the accounts API.
+# TYPE accountapi_client_request_duration_seconds histogram
+accountapi_client_request_duration_seconds_bucket{operation="create",method="POST",status_class="4xx",le="0.01"} 1
+accountapi_client_request_duration_seconds_bucket{operation="create",method="POST",status_class="4xx",le="0.1"} 1
+accountapi_client_request_duration_seconds_bucket{operation="create",method="POST",status_class="4xx",le="+Inf"} 1
+accountapi_client_request_duration_seconds_sum{operation="create",method="POST",status_class="4xx"} 0.005
+accountapi_client_request_duration_seconds_count{operation="create",method="POST",status_class="4xx"} 1
+accountapi_client_request_duration_seconds_bucket{operation="fetch",method="GET",status_class="2xx",le="0.01"} 1
+accountapi_client_request_duration_seconds_bucket{operation="fetch",method="GET",status_class="2xx",le="0.1"} 2
+accountapi_client_request_duration_seconds_bucket{operation="fetch",method="GET",status_class="2xx",le="+Inf"} 3
+accountapi_client_request_duration_seconds_sum{operation="fetch",method="GET",status_class="2xx"} 1.055
+accountapi_client_request_duration_seconds_count{operation="fetch",method="GET",status_class="2xx"} 3
+`
+	assert.Equal(t, expected, out.String())
+}
+
+// metrics-test-2 - end to end, requests through the client should be counted by operation, method and status class,
+// and served by the handler
+func TestMetricsMiddleware(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	c := NewDefaultClient()
+	metrics := c.SetMetrics()
+	_, err := c.SendWithCtx(WithOperation(context.Background(), "fetch"), Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	_, err = c.SendWithCtx(WithOperation(context.Background(), "delete"), Request{Method: http.MethodDelete, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	_, err = c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, string(body), `accountapi_client_requests_total{operation="fetch",method="GET",status_class="2xx"} 1`)
+	assert.Contains(t, string(body), `accountapi_client_requests_total{operation="delete",method="DELETE",status_class="4xx"} 1`)
+	assert.Contains(t, string(body), `accountapi_client_requests_total{operation="unknown",method="GET",status_class="2xx"} 1`)
+}
+
+// metrics-test-3 - transport errors have no status, so they get a class of their own
+func TestStatusClass(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "2xx", statusClass(&http.Response{StatusCode: http.StatusCreated}, nil))
+	assert.Equal(t, "5xx", statusClass(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil))
+	assert.Equal(t, "error", statusClass(nil, errors.New("connection reset by peer")))
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Doer is anything that can execute a http.Request. the stl *http.Client satisfies it, and so does every layer of
+// the middleware chain
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc lets a plain function act as a Doer, the same way http.HandlerFunc works for handlers
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer with extra behaviour (logging, auth, metrics...) and hands back a new Doer
+type Middleware func(next Doer) Doer
+
+// registers middlewares on the default client
+func Use(middlewares ...Middleware) {
+	DefaultClient.Use(middlewares...)
+}
+
+// registers middlewares on a client struct. the first middleware registered is the outermost one, so it sees the
+// request first and the response last. middlewares should be registered before the client is shared between goroutines
+func (c *Client) Use(middlewares ...Middleware) {
+	c.Middlewares = append(c.Middlewares, middlewares...)
+}
+
+// builds the Doer that actually gets called for a request; the client's Doer, or else its http client (or the shared
+// fallback one if it has neither), wrapped by every registered middleware. it never modifies the client
+func (c *Client) chain() Doer {
+	var doer Doer = fallbackHTTPClient
+	if c.Doer != nil {
+		doer = c.Doer
+	} else if c.HTTPClient != nil {
+		doer = c.HTTPClient
+	}
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		doer = c.Middlewares[i](doer)
+	}
+	return doer
+}
+
+type attemptKey struct{}
+
+// tags a context with the attempt number of the request it belongs to
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// returns which attempt (starting at 1) a request is on. useful inside a middleware, where retries are otherwise invisible
+func AttemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+type operationKey struct{}
+
+// tags a context with the logical operation (e.g. "create" or "fetch") a request is part of, so middlewares can scope
+// limits, metrics and logs by it
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// returns the operation a request was tagged with via WithOperation(), or an empty string
+func OperationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// built-in middleware that sets a static set of headers on every request, without overriding headers set on the Request
+func HeadersMiddleware(headers map[string]string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			for key, value := range headers {
+				if req.Header.Get(key) == "" {
+					req.Header.Set(key, value)
+				}
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// built-in middleware that sets the User-Agent header on every request
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", userAgent)
+			return next.Do(req)
+		})
+	}
+}
+
+// built-in middleware that tags every request with a unique X-Request-Id header, unless the caller already set one
+func RequestIDMiddleware() Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Request-Id") != "" {
+				return next.Do(req)
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Request-Id", uuid.New().String())
+			return next.Do(req)
+		})
+	}
+}
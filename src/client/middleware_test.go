@@ -0,0 +1,102 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helper middleware that records the order it was called in
+func recordingMiddleware(name string, mu *sync.Mutex, calls *[]string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			*calls = append(*calls, name+":before")
+			mu.Unlock()
+			resp, err := next.Do(req)
+			mu.Lock()
+			*calls = append(*calls, name+":after")
+			mu.Unlock()
+			return resp, err
+		})
+	}
+}
+
+// middleware-test-1 - middlewares run in the order they were registered, outermost first
+func TestMiddlewareOrder(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+	defer mockServer.Close()
+
+	var mu sync.Mutex
+	var calls []string
+	c := NewDefaultClient()
+	c.Use(recordingMiddleware("first", &mu, &calls), recordingMiddleware("second", &mu, &calls))
+
+	_, err := c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first:before", "second:before", "second:after", "first:after"}, calls)
+}
+
+// middleware-test-2 - built-in header middlewares should set headers without clobbering the caller's
+func TestBuiltInHeaderMiddlewares(t *testing.T) {
+	t.Parallel()
+	headers := make(chan http.Header, 1)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+	}))
+	defer mockServer.Close()
+
+	c := NewDefaultClient()
+	c.Use(
+		HeadersMiddleware(map[string]string{"Accept": "application/vnd.api+json", "X-Team": "payments"}),
+		UserAgentMiddleware("interview-accountapi/test"),
+		RequestIDMiddleware(),
+	)
+
+	_, err := c.Send(Request{
+		Method:  http.MethodGet,
+		BaseURL: mockServer.URL,
+		Headers: map[string]string{"Accept": "application/json"},
+	})
+	assert.Nil(t, err)
+
+	received := <-headers
+	assert.Equal(t, "application/json", received.Get("Accept"), "request headers should win over middleware defaults")
+	assert.Equal(t, "payments", received.Get("X-Team"))
+	assert.Equal(t, "interview-accountapi/test", received.Get("User-Agent"))
+	assert.NotEmpty(t, received.Get("X-Request-Id"))
+}
+
+// middleware-test-3 - every retry goes back through the chain, with the attempt number on the request context
+func TestMiddlewareSeesRetries(t *testing.T) {
+	t.Parallel()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	var attempts []int
+	c := NewDefaultClient()
+	c.SetRetryPolicy(&RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            time.Millisecond,
+		MaxDelay:             time.Millisecond,
+		RetryableStatusCodes: map[int]bool{http.StatusServiceUnavailable: true},
+		RetryableMethods:     map[string]bool{http.MethodGet: true},
+	})
+	c.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			attempts = append(attempts, AttemptFromContext(req.Context()))
+			return next.Do(req)
+		})
+	})
+
+	_, err := c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
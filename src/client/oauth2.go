@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// an oauth2 access token
+type Token struct {
+	AccessToken string
+	TokenType   string
+	Expiry      time.Time
+}
+
+// a token is usable until `before` ahead of its expiry. a zero Expiry never expires
+func (t *Token) validFor(before time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(before).Before(t.Expiry))
+}
+
+// TokenSource hands out access tokens for OAuth2Middleware
+type TokenSource interface {
+	// returns a usable token, fetching a new one if needed
+	Token(ctx context.Context) (*Token, error)
+	// tells the source the backend rejected this token, so the next call to Token() fetches a fresh one. a token that
+	// has already been replaced is ignored, so a burst of 401s only causes a single refresh
+	Invalidate(stale *Token)
+}
+
+// ClientCredentials is a TokenSource for the oauth2 client credentials grant (RFC 6749 section 4.4). tokens are cached
+// until RefreshBefore ahead of their expiry, and concurrent goroutines share a single in-flight token request
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// token requests go through this rather than through the Client being authenticated. defaults to a http.Client
+	// with a 10 second timeout
+	HTTPClient Doer
+	// how long before expiry a cached token is refreshed. defaults to 30 seconds
+	RefreshBefore time.Duration
+
+	mu       sync.Mutex
+	token    *Token
+	inflight *tokenCall
+}
+
+// a token request shared by every goroutine that needed a token while it was running
+type tokenCall struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+func (c *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	c.mu.Lock()
+	if c.token.validFor(c.refreshBefore()) {
+		token := c.token
+		c.mu.Unlock()
+		return token, nil
+	}
+	call := c.inflight
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		c.inflight = call
+		go c.fetch(call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+		return call.token, call.err
+	}
+}
+
+func (c *ClientCredentials) Invalidate(stale *Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == stale {
+		c.token = nil
+	}
+}
+
+func (c *ClientCredentials) refreshBefore() time.Duration {
+	if c.RefreshBefore == 0 {
+		return 30 * time.Second
+	}
+	return c.RefreshBefore
+}
+
+// runs a token request on behalf of every goroutine waiting on call. it isn't tied to any one caller's context, so a
+// caller giving up doesn't fail the request for the others
+func (c *ClientCredentials) fetch(call *tokenCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	call.token, call.err = c.requestToken(ctx)
+
+	c.mu.Lock()
+	if call.err == nil {
+		c.token = call.token
+	}
+	c.inflight = nil
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// sends the client credentials grant to the token endpoint
+func (c *ClientCredentials) requestToken(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": []string{"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	doer := c.HTTPClient
+	if doer == nil {
+		doer = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting oauth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading oauth2 token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth2 token endpoint returned %d: %s", resp.StatusCode, body)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decoding oauth2 token response: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2 token endpoint returned no access_token")
+	}
+	token := &Token{AccessToken: payload.AccessToken, TokenType: payload.TokenType}
+	if payload.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// built-in middleware that sets a bearer token from the TokenSource on every request. if the backend answers 401 the
+// token is invalidated and the request is sent once more with a fresh one, unless its body can't be rewound
+func OAuth2Middleware(ts TokenSource) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := ts.Token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next.Do(withBearer(req, token))
+			if err != nil || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+
+			// the token was rejected either way, so the next request shouldn't reuse it even if this one can't go again
+			ts.Invalidate(token)
+			// the body of the first attempt has been consumed, so we can only go again if it can be rewound
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return resp, nil
+			}
+			fresh, err := ts.Token(req.Context())
+			if err != nil {
+				return resp, nil
+			}
+			resp.Body.Close()
+
+			retry := req.Clone(req.Context())
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			return next.Do(withBearer(retry, fresh))
+		})
+	}
+}
+
+// copy of a request with the Authorization header set from a token
+func withBearer(req *http.Request, token *Token) *http.Request {
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+	return req
+}
+
+// authenticates every request sent by the default client with tokens from ts
+func SetTokenSource(ts TokenSource) {
+	DefaultClient.SetTokenSource(ts)
+}
+
+// authenticates every request sent by a client struct with tokens from ts. this registers OAuth2Middleware, so it
+// should be called once, before the client is shared between goroutines
+func (c *Client) SetTokenSource(ts TokenSource) {
+	c.Use(OAuth2Middleware(ts))
+}
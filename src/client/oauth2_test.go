@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that spins up a token endpoint handing out token-1, token-2... with the given lifetime
+func newTokenServer(t *testing.T, expiresIn int, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		id, secret, ok := req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client-id", id)
+		assert.Equal(t, "client-secret", secret)
+		assert.Nil(t, req.ParseForm())
+		assert.Equal(t, "client_credentials", req.PostForm.Get("grant_type"))
+		assert.Equal(t, "accounts:read accounts:write", req.PostForm.Get("scope"))
+
+		// slow enough that concurrent callers pile up behind the first request
+		time.Sleep(20 * time.Millisecond)
+		n := atomic.AddInt32(calls, 1)
+		fmt.Fprintf(writer, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": %d}`, n, expiresIn)
+	}))
+}
+
+// helper function that returns a ClientCredentials source pointed at a token server
+func newClientCredentials(tokenServer *httptest.Server) *ClientCredentials {
+	return &ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Scopes:       []string{"accounts:read", "accounts:write"},
+	}
+}
+
+// oauth2-test-1 - concurrent callers should share one token request, and the token should be cached afterwards
+func TestClientCredentialsSingleFlightAndCache(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	tokenServer := newTokenServer(t, 3600, &calls)
+	defer tokenServer.Close()
+	source := newClientCredentials(tokenServer)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "token-1", token.AccessToken)
+		}()
+	}
+	wg.Wait()
+
+	token, err := source.Token(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// oauth2-test-2 - a token inside the refresh window should be replaced before it expires
+func TestClientCredentialsProactiveRefresh(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	tokenServer := newTokenServer(t, 10, &calls)
+	defer tokenServer.Close()
+	source := newClientCredentials(tokenServer)
+	source.RefreshBefore = time.Minute
+
+	first, err := source.Token(context.Background())
+	assert.Nil(t, err)
+	second, err := source.Token(context.Background())
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.AccessToken, second.AccessToken, "a token expiring within RefreshBefore should be refreshed")
+}
+
+// oauth2-test-3 - the client should send the bearer token, and on a 401 refresh it and retry exactly once
+func TestOAuth2MiddlewareRetriesOnceOn401(t *testing.T) {
+	t.Parallel()
+	var tokenCalls int32
+	tokenServer := newTokenServer(t, 3600, &tokenCalls)
+	defer tokenServer.Close()
+
+	var apiCalls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Equal(t, `{"data": {}}`, string(body))
+		// token-1 has been revoked on the server side
+		if req.Header.Get("Authorization") != "Bearer token-2" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writer.WriteHeader(http.StatusCreated)
+	}))
+	defer mockServer.Close()
+
+	c := NewDefaultClient()
+	c.SetTokenSource(newClientCredentials(tokenServer))
+	resp, err := c.Send(Request{Method: http.MethodPost, BaseURL: mockServer.URL, Body: []byte(`{"data": {}}`)})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&apiCalls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tokenCalls))
+}
+
+// oauth2-test-4 - a second 401 is handed back to the caller rather than looping
+func TestOAuth2MiddlewareGivesUpAfterOneRetry(t *testing.T) {
+	t.Parallel()
+	var tokenCalls int32
+	tokenServer := newTokenServer(t, 3600, &tokenCalls)
+	defer tokenServer.Close()
+
+	var apiCalls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		writer.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer mockServer.Close()
+
+	c := NewDefaultClient()
+	c.SetTokenSource(newClientCredentials(tokenServer))
+	resp, err := c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&apiCalls))
+}
+
+// oauth2-test-5 - a failing token endpoint should fail the request with an error
+func TestClientCredentialsTokenEndpointError(t *testing.T) {
+	t.Parallel()
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(writer, `{"error": "invalid_client"}`)
+	}))
+	defer tokenServer.Close()
+
+	_, err := newClientCredentials(tokenServer).Token(context.Background())
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid_client")
+	}
+}
+
+// oauth2-test-6 - a 401 on a request whose body can't be rewound isn't retried, but the rejected token is still
+// dropped so the next request gets a fresh one
+func TestOAuth2MiddlewareInvalidatesWithoutRewind(t *testing.T) {
+	t.Parallel()
+	var tokenCalls int32
+	tokenServer := newTokenServer(t, 3600, &tokenCalls)
+	defer tokenServer.Close()
+	source := newClientCredentials(tokenServer)
+
+	var sent []string
+	doer := OAuth2Middleware(source)(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get("Authorization"))
+		return &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody}, nil
+	}))
+	// a reader http.NewRequest doesn't know how to rewind, so GetBody stays nil
+	req, err := http.NewRequest(http.MethodPost, "http://superfake.com", io.MultiReader(strings.NewReader("{}")))
+	assert.Nil(t, err)
+	assert.Nil(t, req.GetBody)
+
+	resp, err := doer.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer token-1"}, sent, "the request can't be sent again")
+
+	token, err := source.Token(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "token-2", token.AccessToken, "the rejected token shouldn't have stayed cached")
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that can be shared by any number of goroutines. it refills at a steady rate of
+// requests per second, and holds up to burst tokens. the limiter also backs off on its own when the server says it
+// is being rate limited, see Observe()
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	// nothing gets through before this point in time. pushed forward by 429s and exhausted rate limit headers
+	pausedUntil time.Time
+}
+
+// constructor method for a RateLimiter that starts with a full bucket. a requestsPerSecond of zero or less never
+// refills the bucket, so once burst requests have gone through every other one waits until its context is done
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// blocks until a request is allowed through, or until the context is done. a request that gives up hands its token back
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 && l.rate > 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if paused := l.pausedUntil.Sub(now); paused > wait {
+		wait = paused
+	}
+	// with no refill rate an empty bucket never fills up again, so there's no point in time worth waiting for
+	exhausted := l.tokens < 0 && l.rate <= 0
+	l.mu.Unlock()
+
+	if exhausted {
+		<-ctx.Done()
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleepWithCtx(ctx, wait); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// tops the bucket up with the tokens earned since the last call
+func (l *RateLimiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+	}
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// holds every request back until the given time
+func (l *RateLimiter) PauseUntil(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// looks at a response for signs of server side rate limiting and pauses the limiter accordingly. a 429 pauses for its
+// Retry-After (or one second if there isn't one), and an exhausted X-RateLimit-Remaining pauses until X-RateLimit-Reset
+func (l *RateLimiter) Observe(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	now := time.Now()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok := retryAfter(resp.Header)
+		if !ok {
+			wait = time.Second
+		}
+		l.PauseUntil(now.Add(wait))
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, ok := rateLimitReset(resp.Header.Get("X-RateLimit-Reset"), now); ok {
+			l.PauseUntil(reset)
+		}
+	}
+}
+
+// X-RateLimit-Reset is either a unix timestamp or a number of seconds from now, depending on the server. anything
+// that would be more than a day away if read as a delta is taken to be a timestamp
+func rateLimitReset(value string, now time.Time) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false
+	}
+	if seconds > 24*60*60 {
+		return time.Unix(seconds, 0), true
+	}
+	return now.Add(time.Duration(seconds) * time.Second), true
+}
+
+// picks the scope a request is rate limited in
+type RateLimitKeyFunc func(req *http.Request) string
+
+// scopes rate limits by the host a request is sent to
+func ByHost(req *http.Request) string {
+	return req.URL.Host
+}
+
+// scopes rate limits by the operation a request was tagged with, see WithOperation()
+func ByOperation(req *http.Request) string {
+	return OperationFromContext(req.Context())
+}
+
+// KeyedRateLimiter keeps a separate RateLimiter per scope, e.g. per host or per operation. limiters are created
+// lazily, each with the same rate and burst
+type KeyedRateLimiter struct {
+	rate  float64
+	burst int
+	key   RateLimitKeyFunc
+
+	mu       sync.Mutex
+	limiters map[string]*RateLimiter
+}
+
+// constructor method for a KeyedRateLimiter
+func NewKeyedRateLimiter(requestsPerSecond float64, burst int, key RateLimitKeyFunc) *KeyedRateLimiter {
+	return &KeyedRateLimiter{
+		rate:     requestsPerSecond,
+		burst:    burst,
+		key:      key,
+		limiters: make(map[string]*RateLimiter),
+	}
+}
+
+// returns the limiter for the scope a request falls into
+func (k *KeyedRateLimiter) Limiter(req *http.Request) *RateLimiter {
+	key := k.key(req)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	limiter, ok := k.limiters[key]
+	if !ok {
+		limiter = NewRateLimiter(k.rate, k.burst)
+		k.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// built-in middleware that holds requests back to stay under a single, client wide rate limit
+func RateLimitMiddleware(l *RateLimiter) Middleware {
+	return KeyedRateLimitMiddleware(&KeyedRateLimiter{
+		key:      func(*http.Request) string { return "" },
+		limiters: map[string]*RateLimiter{"": l},
+	})
+}
+
+// built-in middleware that holds requests back to stay under a rate limit per scope
+func KeyedRateLimitMiddleware(k *KeyedRateLimiter) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			limiter := k.Limiter(req)
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+			resp, err := next.Do(req)
+			limiter.Observe(resp)
+			return resp, err
+		})
+	}
+}
+
+// limits the default client to requestsPerSecond, with bursts of up to burst requests
+func SetRateLimit(requestsPerSecond float64, burst int) {
+	DefaultClient.SetRateLimit(requestsPerSecond, burst)
+}
+
+// limits a client struct to requestsPerSecond, with bursts of up to burst requests. this registers a
+// RateLimitMiddleware, so it should be called once, before the client is shared between goroutines
+func (c *Client) SetRateLimit(requestsPerSecond float64, burst int) {
+	c.Use(RateLimitMiddleware(NewRateLimiter(requestsPerSecond, burst)))
+}
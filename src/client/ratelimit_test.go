@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ratelimit-test-1 - after the burst is used up, requests should be spaced out at the configured rate
+func TestRateLimiterBurstThenRate(t *testing.T) {
+	t.Parallel()
+	limiter := NewRateLimiter(100, 5)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, limiter.Wait(context.Background()))
+	}
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Millisecond), "the burst should go straight through")
+
+	start = time.Now()
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(40*time.Millisecond), "5 more requests at 100/s should take ~50ms")
+}
+
+// ratelimit-test-2 - the limiter should be shared fairly between goroutines
+func TestRateLimiterConcurrent(t *testing.T) {
+	t.Parallel()
+	limiter := NewRateLimiter(200, 1)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, limiter.Wait(context.Background()))
+		}()
+	}
+	wg.Wait()
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(80*time.Millisecond), "20 requests at 200/s should take ~95ms")
+}
+
+// ratelimit-test-3 - waiting should give up when the context does
+func TestRateLimiterRespectsContext(t *testing.T) {
+	t.Parallel()
+	limiter := NewRateLimiter(1, 1)
+	assert.Nil(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
+
+// ratelimit-test-3b - with no refill rate, requests past the burst should be held back rather than let through
+func TestRateLimiterWithoutRate(t *testing.T) {
+	t.Parallel()
+	for _, rate := range []float64{0, -5} {
+		limiter := NewRateLimiter(rate, 2)
+		assert.Nil(t, limiter.Wait(context.Background()))
+		assert.Nil(t, limiter.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		start := time.Now()
+		assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(20*time.Millisecond))
+		cancel()
+		assert.Equal(t, 0.0, limiter.tokens, "the token should have been handed back")
+	}
+}
+
+// ratelimit-test-4 - a 429 with Retry-After, or exhausted X-RateLimit-* headers, should pause the limiter
+func TestRateLimiterObserve(t *testing.T) {
+	t.Parallel()
+	limiter := NewRateLimiter(1000, 10)
+	limiter.Observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"1"}}})
+	assert.WithinDuration(t, time.Now().Add(time.Second), limiter.pausedUntil, 50*time.Millisecond)
+
+	limiter = NewRateLimiter(1000, 10)
+	reset := time.Now().Add(time.Hour).Unix()
+	limiter.Observe(&http.Response{StatusCode: http.StatusOK, Header: http.Header{
+		"X-Ratelimit-Remaining": []string{"0"},
+		"X-Ratelimit-Reset":     []string{strconv.FormatInt(reset, 10)},
+	}})
+	assert.Equal(t, reset, limiter.pausedUntil.Unix())
+
+	limiter = NewRateLimiter(1000, 10)
+	limiter.Observe(&http.Response{StatusCode: http.StatusOK, Header: http.Header{"X-Ratelimit-Remaining": []string{"5"}}})
+	assert.True(t, limiter.pausedUntil.IsZero(), "a healthy response shouldn't pause anything")
+}
+
+// ratelimit-test-5 - end to end, a 429 from the server should hold back the next request through the client
+func TestRateLimitMiddlewareSlowsDownOn429(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var arrivals []time.Time
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		first := len(arrivals) == 1
+		mu.Unlock()
+		if first {
+			writer.Header().Set("Retry-After", "1")
+			writer.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer mockServer.Close()
+
+	c := NewDefaultClient()
+	c.SetRateLimit(1000, 10)
+	resp, err := c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	resp, err = c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.GreaterOrEqual(t, int64(arrivals[1].Sub(arrivals[0])), int64(900*time.Millisecond))
+}
+
+// ratelimit-test-6 - keyed limiters should keep separate buckets per operation
+func TestKeyedRateLimiterByOperation(t *testing.T) {
+	t.Parallel()
+	keyed := NewKeyedRateLimiter(1, 1, ByOperation)
+	newRequest := func(operation string) *http.Request {
+		req, _ := http.NewRequestWithContext(WithOperation(context.Background(), operation), http.MethodGet, "http://superfake.com", nil)
+		return req
+	}
+
+	assert.Same(t, keyed.Limiter(newRequest("create")), keyed.Limiter(newRequest("create")))
+	assert.NotSame(t, keyed.Limiter(newRequest("create")), keyed.Limiter(newRequest("fetch")))
+
+	// the single token of "create" being spent shouldn't hold up "fetch"
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, keyed.Limiter(newRequest("create")).Wait(ctx))
+	assert.Nil(t, keyed.Limiter(newRequest("fetch")).Wait(ctx))
+	assert.NotNil(t, keyed.Limiter(newRequest("create")).Wait(ctx))
+}
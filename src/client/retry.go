@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how a Client retries a Request that failed with a transport error or a retryable status code.
+// a nil policy on the Client means every Request is sent exactly once
+type RetryPolicy struct {
+	// total number of attempts, including the first one
+	MaxAttempts int
+	// the backoff before attempt n is a random duration between 0 and min(MaxDelay, BaseDelay * 2^(n-1)) ("full jitter")
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// status codes that are worth another go
+	RetryableStatusCodes map[int]bool
+	// http methods that are safe to send more than once. POST/PATCH are left out by default as they aren't idempotent
+	RetryableMethods map[string]bool
+	// also retry a Request of any method if it carries an Idempotency-Key header. only turn this on if the server
+	// honours the key, otherwise a resent POST can create things twice. off by default
+	RetryIdempotencyKeyed bool
+}
+
+// header that lets the server recognise a resent request as the same logical operation
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// constructor method for a sane retry policy; 3 attempts, 100ms base delay capped at 5s, retrying GET and DELETE on
+// 429/502/503/504 and on transport errors such as connection resets
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		RetryableStatusCodes: map[int]bool{
+			http.StatusTooManyRequests:    true,
+			http.StatusBadGateway:         true,
+			http.StatusServiceUnavailable: true,
+			http.StatusGatewayTimeout:     true,
+		},
+		RetryableMethods: map[string]bool{
+			http.MethodGet:    true,
+			http.MethodDelete: true,
+		},
+	}
+}
+
+// helper function to set the retry policy on the default client
+func SetRetryPolicy(p *RetryPolicy) {
+	DefaultClient.Retry = p
+}
+
+// helper function to set the retry policy on a client struct
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	c.Retry = p
+}
+
+// how many times a Request should be attempted under this policy
+func (p *RetryPolicy) attemptsFor(r Request) int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	if !p.RetryableMethods[r.Method] && !(p.RetryIdempotencyKeyed && r.Headers[IdempotencyKeyHeader] != "") {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// decides if the outcome of an attempt is worth retrying. context errors are never retried, the caller gave up, and
+// neither is an open circuit breaker, which is there to stop us hammering the backend
+func (p *RetryPolicy) shouldRetry(resp *Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrCircuitOpen)
+	}
+	return p.RetryableStatusCodes[resp.StatusCode]
+}
+
+// works out how long to wait before the next attempt. a Retry-After header from the server wins over our own backoff,
+// but is still capped at MaxDelay when that is set, so a server can't stall the caller for longer than the policy allows
+func (p *RetryPolicy) backoff(attempt int, resp *Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Headers); ok {
+			if p.MaxDelay > 0 && wait > p.MaxDelay {
+				return p.MaxDelay
+			}
+			return wait
+		}
+	}
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	// double BaseDelay once per attempt, stopping at MaxDelay (or before the shift would overflow) so a high attempt
+	// count can't wrap round to a zero delay
+	limit := p.MaxDelay
+	if limit <= 0 {
+		limit = time.Duration(math.MaxInt64)
+	}
+	ceiling := p.BaseDelay
+	for i := 1; i < attempt && ceiling < limit; i++ {
+		if ceiling > limit/2 {
+			ceiling = limit
+			break
+		}
+		ceiling <<= 1
+	}
+	if ceiling > limit {
+		ceiling = limit
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// parses a Retry-After header, which is either a number of seconds or a http date
+func retryAfter(headers http.Header) (time.Duration, bool) {
+	value := headers.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// blocks for d, or until the context is done, whichever comes first
+func sleepWithCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that returns a fresh client with a fast retry policy for testing
+func newRetryingClient() *Client {
+	c := NewDefaultClient()
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+	c.SetRetryPolicy(policy)
+	return c
+}
+
+// retry-test-1 - a GET that fails twice with a 503 should succeed on the third attempt
+func TestRetryTransientStatus(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	resp, err := newRetryingClient().Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// retry-test-2 - once the attempts are used up the last response is handed back as-is
+func TestRetryGivesUp(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusBadGateway)
+	}))
+	defer mockServer.Close()
+
+	resp, err := newRetryingClient().Send(Request{Method: http.MethodDelete, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// retry-test-3 - POST isn't idempotent, so it shouldn't be retried by the default policy
+func TestRetrySkipsNonIdempotentMethods(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	resp, err := newRetryingClient().Send(Request{Method: http.MethodPost, BaseURL: mockServer.URL, Body: []byte("{}")})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// retry-test-4 - the request body should be sent in full on every attempt
+func TestRetryRewindsBody(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	bodies := make(chan string, 3)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies <- string(body)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	c := newRetryingClient()
+	c.Retry.RetryableMethods[http.MethodPut] = true
+	_, err := c.Send(Request{Method: http.MethodPut, BaseURL: mockServer.URL, Body: []byte(`{"foo": "bar"}`)})
+	assert.Nil(t, err)
+	close(bodies)
+	for body := range bodies {
+		assert.Equal(t, `{"foo": "bar"}`, body)
+	}
+}
+
+// retry-test-5 - a cancelled context should stop the retry loop straight away
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.Header().Set("Retry-After", "10")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := newRetryingClient()
+	c.Retry.MaxDelay = time.Minute
+	start := time.Now()
+	resp, err := c.sendWithCtx(ctx, Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "we should not have waited out the Retry-After header")
+}
+
+// retry-test-6 - Retry-After in seconds and as a http date
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+	wait, ok := retryAfter(http.Header{"Retry-After": []string{"2"}})
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+
+	wait, ok = retryAfter(http.Header{"Retry-After": []string{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}})
+	assert.True(t, ok)
+	assert.Greater(t, int64(wait), int64(59*time.Minute))
+
+	_, ok = retryAfter(http.Header{})
+	assert.False(t, ok)
+}
+
+// retry-test-7 - the jittered backoff should never go above the cap
+func TestBackoffIsCapped(t *testing.T) {
+	t.Parallel()
+	policy := DefaultRetryPolicy()
+	for attempt := 1; attempt < 20; attempt++ {
+		assert.LessOrEqual(t, int64(policy.backoff(attempt, nil)), int64(policy.MaxDelay))
+	}
+}
+
+// retry-test-7b - a Retry-After longer than MaxDelay is capped, and without a MaxDelay a high attempt count mustn't
+// overflow down to no delay at all
+func TestBackoffCapsRetryAfterAndOverflow(t *testing.T) {
+	t.Parallel()
+	policy := DefaultRetryPolicy()
+	resp := &Response{Headers: http.Header{"Retry-After": []string{"86400"}}}
+	assert.Equal(t, policy.MaxDelay, policy.backoff(1, resp))
+	policy.MaxDelay = 0
+	assert.Equal(t, 24*time.Hour, policy.backoff(1, resp), "without a cap the server's Retry-After is honoured")
+
+	policy.BaseDelay = time.Second
+	nonZero := false
+	for i := 0; i < 10; i++ {
+		if policy.backoff(100, nil) > 0 {
+			nonZero = true
+		}
+	}
+	assert.True(t, nonZero, "the backoff shouldn't have wrapped round to zero")
+}
+
+// retry-test-8 - with RetryIdempotencyKeyed a POST carrying an Idempotency-Key is resent, and keeps the same key on
+// every attempt
+func TestRetryIdempotencyKeyedPost(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	keys := make(chan string, 3)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		keys <- req.Header.Get(IdempotencyKeyHeader)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusCreated)
+	}))
+	defer mockServer.Close()
+
+	c := newRetryingClient()
+	c.Retry.RetryIdempotencyKeyed = true
+	resp, err := c.Send(Request{
+		Method:  http.MethodPost,
+		BaseURL: mockServer.URL,
+		Headers: map[string]string{IdempotencyKeyHeader: "a-key"},
+		Body:    []byte("{}"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	close(keys)
+	for key := range keys {
+		assert.Equal(t, "a-key", key)
+	}
+}
+
+// retry-test-9 - the default policy doesn't resend a keyed POST, as not every server honours the key
+func TestRetrySkipsIdempotencyKeyedPostByDefault(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	resp, err := newRetryingClient().Send(Request{
+		Method:  http.MethodPost,
+		BaseURL: mockServer.URL,
+		Headers: map[string]string{IdempotencyKeyHeader: "a-key"},
+		Body:    []byte("{}"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
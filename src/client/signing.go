@@ -0,0 +1,159 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the headers covered by the signature, in the order they are signed
+var signedHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+// built-in middleware that signs every request with a http signature (draft-cavage-http-signatures), as required by
+// the real form3 environments. a Digest header is computed over the request body and the Signature header covers
+// (request-target), host, date and digest using RSA-SHA256. retries are signed again, with a fresh Date header
+func SigningMiddleware(keyID string, key *rsa.PrivateKey) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+
+			body, err := readBody(req)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Digest", digest(body))
+			if req.Header.Get("Date") == "" {
+				req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+			}
+
+			hashed := sha256.Sum256([]byte(signingString(req)))
+			signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+			if err != nil {
+				return nil, fmt.Errorf("signing request: %w", err)
+			}
+			req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+				keyID, strings.Join(signedHeaders, " "), base64.StdEncoding.EncodeToString(signature)))
+
+			return next.Do(req)
+		})
+	}
+}
+
+// checks the Digest and Signature headers of an incoming request against a public key. this is the server side of
+// SigningMiddleware, handy for httptest servers
+func VerifySignature(req *http.Request, key *rsa.PublicKey) error {
+	params := parseSignatureHeader(req.Header.Get("Signature"))
+	if params["algorithm"] != "rsa-sha256" || params["headers"] != strings.Join(signedHeaders, " ") {
+		return errors.New("unsupported or missing signature parameters")
+	}
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	if req.Header.Get("Digest") != digest(body) {
+		return errors.New("digest does not match the request body")
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req)))
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature)
+}
+
+// parses a private key from PEM, in either PKCS#1 ("RSA PRIVATE KEY") or PKCS#8 ("PRIVATE KEY") form
+func ParseRSAPrivateKeyFromPEM(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// helper function that reads a PEM encoded RSA private key from disk
+func LoadRSAPrivateKeyFromFile(path string) (*rsa.PrivateKey, error) {
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRSAPrivateKeyFromPEM(pemBytes)
+}
+
+// builds the string that gets signed, one "name: value" line per signed header
+func signingString(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	lines := []string{
+		fmt.Sprintf("(request-target): %s %s", strings.ToLower(req.Method), req.URL.RequestURI()),
+		"host: " + host,
+		"date: " + req.Header.Get("Date"),
+		"digest: " + req.Header.Get("Digest"),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SHA-256 digest of a body, in the format of the Digest header
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// reads the whole body of a request without consuming it, so it can still be sent (or read by a handler) afterwards
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// splits a Signature header into its key="value" parameters
+func parseSignatureHeader(header string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return params
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helper function that generates a throwaway RSA key for signing tests
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signing-test-1 - a local server should be able to verify what the middleware signed, body and all
+func TestSigningMiddlewareVerifiesOnServer(t *testing.T) {
+	t.Parallel()
+	key := generateTestKey(t)
+	verified := make(chan error, 1)
+	signatures := make(chan string, 1)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		verified <- VerifySignature(req, &key.PublicKey)
+		signatures <- req.Header.Get("Signature")
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Equal(t, `{"data": {}}`, string(body), "verifying should not consume the body")
+	}))
+	defer mockServer.Close()
+
+	c := NewDefaultClient()
+	c.Use(SigningMiddleware("75a8ba12-fff2-4a52-ad8a-e8b34c5ccec8", key))
+	resp, err := c.Send(Request{
+		Method:      http.MethodPost,
+		BaseURL:     mockServer.URL + "/v1/organisation/accounts",
+		QueryParams: map[string]string{"foo": "bar"},
+		Body:        []byte(`{"data": {}}`),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, <-verified)
+
+	signature := <-signatures
+	assert.Contains(t, signature, `keyId="75a8ba12-fff2-4a52-ad8a-e8b34c5ccec8"`)
+	assert.Contains(t, signature, `algorithm="rsa-sha256"`)
+	assert.Contains(t, signature, `headers="(request-target) host date digest"`)
+}
+
+// signing-test-2 - tampering with the body or checking against the wrong key should fail verification
+func TestVerifySignatureRejectsTampering(t *testing.T) {
+	t.Parallel()
+	key := generateTestKey(t)
+	var signed *http.Request
+	capture := DoerFunc(func(req *http.Request) (*http.Response, error) {
+		signed = req
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	req, err := buildRequest(Request{Method: http.MethodPost, BaseURL: "http://superfake.com/accounts", Body: []byte("original")})
+	assert.Nil(t, err)
+	_, err = SigningMiddleware("key-id", key)(capture).Do(req)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifySignature(signed, &key.PublicKey))
+
+	assert.NotNil(t, VerifySignature(signed, &generateTestKey(t).PublicKey), "a different key should not verify")
+
+	signed.Body = ioutil.NopCloser(strings.NewReader("tampered"))
+	signed.GetBody = nil
+	assert.NotNil(t, VerifySignature(signed, &key.PublicKey), "a different body should not verify")
+}
+
+// signing-test-3 - private keys can come in as PKCS#1 or PKCS#8 PEM, from bytes or from disk
+func TestParseRSAPrivateKeyFromPEM(t *testing.T) {
+	t.Parallel()
+	key := generateTestKey(t)
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	parsed, err := ParseRSAPrivateKeyFromPEM(pkcs1)
+	assert.Nil(t, err)
+	assert.True(t, key.Equal(parsed))
+
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes})
+	path := filepath.Join(t.TempDir(), "key.pem")
+	assert.Nil(t, ioutil.WriteFile(path, pkcs8, 0600))
+	parsed, err = LoadRSAPrivateKeyFromFile(path)
+	assert.Nil(t, err)
+	assert.True(t, key.Equal(parsed))
+
+	_, err = ParseRSAPrivateKeyFromPEM([]byte("not a pem"))
+	assert.NotNil(t, err)
+}
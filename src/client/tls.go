@@ -0,0 +1,238 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOptions describes the tls setup of a client: an optional client certificate for mutual tls, private CAs to trust,
+// protocol constraints and optional public key pinning. certificates and CAs can come from files or from PEM bytes
+type TLSOptions struct {
+	// client certificate and key. when loaded from files, the files are watched and a rotated pair is picked up on
+	// the next tls handshake, without rebuilding the client
+	CertFile string
+	KeyFile  string
+	CertPEM  []byte
+	KeyPEM   []byte
+	// how often the cert and key files are checked for changes. defaults to 10 seconds
+	CertReloadInterval time.Duration
+
+	// CA bundles to trust on top of (or instead of, see SystemRoots) the system pool
+	CAFile string
+	CAPEM  []byte
+	// keeps the system CA pool alongside the private CAs; when false only the private CAs are trusted
+	SystemRoots bool
+
+	// defaults to tls 1.2
+	MinVersion uint16
+	// restricts the tls 1.2 cipher suites. tls 1.3 suites aren't configurable in the golang stl
+	CipherSuites []uint16
+	// overrides the server name used for SNI and certificate verification
+	ServerName string
+
+	// base64 encoded SHA-256 hashes of a SubjectPublicKeyInfo. when set, at least one certificate in the verified chain
+	// has to match one of them
+	PinnedSPKIHashes []string
+}
+
+// builds a *tls.Config out of the options
+func (o TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: o.CipherSuites,
+		ServerName:   o.ServerName,
+	}
+	if o.MinVersion != 0 {
+		cfg.MinVersion = o.MinVersion
+	}
+
+	switch {
+	case o.CertFile != "" || o.KeyFile != "":
+		reloader, err := NewCertReloader(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if o.CertReloadInterval > 0 {
+			reloader.CheckInterval = o.CertReloadInterval
+		}
+		cfg.GetClientCertificate = reloader.GetClientCertificate
+	case len(o.CertPEM) > 0 || len(o.KeyPEM) > 0:
+		cert, err := tls.X509KeyPair(o.CertPEM, o.KeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.CAFile != "" || len(o.CAPEM) > 0 {
+		pool, err := o.certPool()
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if len(o.PinnedSPKIHashes) > 0 {
+		cfg.VerifyPeerCertificate = verifyPins(o.PinnedSPKIHashes)
+	}
+	return cfg, nil
+}
+
+// builds the pool of trusted CAs
+func (o TLSOptions) certPool() (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if o.SystemRoots {
+		system, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("loading system CA pool: %w", err)
+		}
+		pool = system
+	}
+
+	bundles := [][]byte{o.CAPEM}
+	if o.CAFile != "" {
+		caPEM, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		bundles = append(bundles, caPEM)
+	}
+	for _, bundle := range bundles {
+		if len(bundle) > 0 && !pool.AppendCertsFromPEM(bundle) {
+			return nil, errors.New("no certificates found in CA bundle")
+		}
+	}
+	return pool, nil
+}
+
+// returns a tls callback that rejects a verified chain unless one of its certificates matches a pinned SPKI hash
+func verifyPins(pins []string) func([][]byte, [][]*x509.Certificate) error {
+	pinned := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		pinned[pin] = true
+	}
+	return func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				if pinned[SPKIHash(cert)] {
+					return nil
+				}
+			}
+		}
+		return errors.New("server certificate chain does not match any pinned public key")
+	}
+}
+
+// base64 encoded SHA-256 hash of a certificate's SubjectPublicKeyInfo, the format used by PinnedSPKIHashes
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// applies tls options to the default client
+func SetTLSOptions(o TLSOptions) error {
+	return DefaultClient.SetTLSOptions(o)
+}
+
+// applies tls options to a client struct. only the tls config of the existing http.Transport is replaced, so the
+// tuned dialer and connection pool settings are kept
+func (c *Client) SetTLSOptions(o TLSOptions) error {
+	cfg, err := o.Config()
+	if err != nil {
+		return err
+	}
+	httpClient := c.httpClient()
+	if httpClient.Transport == nil {
+		httpClient.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return errors.New("tls options can only be applied to a *http.Transport")
+	}
+	transport.TLSClientConfig = cfg
+	transport.CloseIdleConnections()
+	return nil
+}
+
+// CertReloader serves a client certificate loaded from disk, reloading it whenever the cert or key file changes.
+// the files are checked at most once every CheckInterval, on the tls handshake
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	// defaults to 10 seconds
+	CheckInterval time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// constructor method for a CertReloader. the pair is loaded straight away, so a bad pair is reported up front
+func NewCertReloader(certFile string, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, CheckInterval: 10 * time.Second}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reloads the certificate pair from disk unconditionally
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reload()
+}
+
+func (r *CertReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading client certificate: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	r.lastCheck = time.Now()
+	return nil
+}
+
+// the latest modification time of the cert and key files
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// satisfies tls.Config.GetClientCertificate. if the files changed since the last load they are reloaded; a pair that
+// fails to load (e.g. half way through a rotation) keeps the previous certificate in service
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.lastCheck) >= r.CheckInterval {
+		r.lastCheck = time.Now()
+		if modTime, err := r.latestModTime(); err == nil && !modTime.Equal(r.modTime) {
+			_ = r.reload()
+		}
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a throwaway certificate authority for the tls tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// helper function that creates a self-signed CA
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// helper function that issues a client certificate, returned as PEM encoded cert and key
+func (ca *testCA) issueClientCert(t *testing.T, commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// helper function that starts a tls server requiring client certificates signed by ca. the handler echoes the
+// common name of the client certificate back in a header
+func newMutualTLSServer(ca *testCA) *httptest.Server {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.Header().Set("X-Client-CN", req.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	return server
+}
+
+// helper function that returns the PEM encoded certificate of a httptest tls server, to use as a private CA
+func serverCAPEM(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+// tls-test-1 - a client with a certificate from PEM bytes and the server's CA should get through mutual tls
+func TestMutualTLSFromPEM(t *testing.T) {
+	t.Parallel()
+	ca := newTestCA(t)
+	server := newMutualTLSServer(ca)
+	defer server.Close()
+	certPEM, keyPEM := ca.issueClientCert(t, "accounts-client")
+
+	c := NewDefaultClient()
+	err := c.SetTLSOptions(TLSOptions{CertPEM: certPEM, KeyPEM: keyPEM, CAPEM: serverCAPEM(server), MinVersion: tls.VersionTLS12})
+	assert.Nil(t, err)
+
+	resp, err := c.Send(Request{Method: http.MethodGet, BaseURL: server.URL})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "accounts-client", resp.Headers.Get("X-Client-CN"))
+	}
+	assert.Equal(t, 100, c.HTTPClient.Transport.(*http.Transport).MaxConnsPerHost, "the tuned transport should have been kept")
+}
+
+// tls-test-2 - without a client certificate, or without trusting the server's CA, the handshake should fail
+func TestMutualTLSFailures(t *testing.T) {
+	t.Parallel()
+	ca := newTestCA(t)
+	server := newMutualTLSServer(ca)
+	defer server.Close()
+	certPEM, keyPEM := ca.issueClientCert(t, "accounts-client")
+
+	noCert := NewDefaultClient()
+	assert.Nil(t, noCert.SetTLSOptions(TLSOptions{CAPEM: serverCAPEM(server)}))
+	_, err := noCert.Send(Request{Method: http.MethodGet, BaseURL: server.URL})
+	assert.NotNil(t, err)
+
+	untrusted := NewDefaultClient()
+	assert.Nil(t, untrusted.SetTLSOptions(TLSOptions{CertPEM: certPEM, KeyPEM: keyPEM, CAPEM: ca.pem}))
+	_, err = untrusted.Send(Request{Method: http.MethodGet, BaseURL: server.URL})
+	assert.NotNil(t, err)
+}
+
+// tls-test-3 - a rotated certificate on disk should be picked up without rebuilding the client
+func TestMutualTLSHotReload(t *testing.T) {
+	t.Parallel()
+	ca := newTestCA(t)
+	server := newMutualTLSServer(ca)
+	defer server.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), filepath.Join(dir, "ca.crt")
+	writePair := func(commonName string, modTime time.Time) {
+		certPEM, keyPEM := ca.issueClientCert(t, commonName)
+		assert.Nil(t, ioutil.WriteFile(certFile, certPEM, 0600))
+		assert.Nil(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+		assert.Nil(t, os.Chtimes(certFile, modTime, modTime))
+		assert.Nil(t, os.Chtimes(keyFile, modTime, modTime))
+	}
+	writePair("first", time.Now().Add(-time.Minute))
+	assert.Nil(t, ioutil.WriteFile(caFile, serverCAPEM(server), 0600))
+
+	c := NewDefaultClient()
+	assert.Nil(t, c.SetTLSOptions(TLSOptions{CertFile: certFile, KeyFile: keyFile, CAFile: caFile, CertReloadInterval: time.Nanosecond}))
+
+	resp, err := c.Send(Request{Method: http.MethodGet, BaseURL: server.URL})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "first", resp.Headers.Get("X-Client-CN"))
+	}
+
+	writePair("second", time.Now())
+	// established connections keep the certificate they were opened with
+	c.HTTPClient.CloseIdleConnections()
+	resp, err = c.Send(Request{Method: http.MethodGet, BaseURL: server.URL})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "second", resp.Headers.Get("X-Client-CN"))
+	}
+}
+
+// tls-test-4 - pinning should accept the server's key and reject anything else
+func TestSPKIPinning(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	pinned := NewDefaultClient()
+	assert.Nil(t, pinned.SetTLSOptions(TLSOptions{CAPEM: serverCAPEM(server), PinnedSPKIHashes: []string{SPKIHash(server.Certificate())}}))
+	_, err := pinned.Send(Request{Method: http.MethodGet, BaseURL: server.URL})
+	assert.Nil(t, err)
+
+	wrongPin := NewDefaultClient()
+	assert.Nil(t, wrongPin.SetTLSOptions(TLSOptions{CAPEM: serverCAPEM(server), PinnedSPKIHashes: []string{SPKIHash(newTestCA(t).cert)}}))
+	_, err = wrongPin.Send(Request{Method: http.MethodGet, BaseURL: server.URL})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "pinned")
+	}
+}
+
+// tls-test-5 - bad inputs should be reported when the options are applied, not on the first request
+func TestTLSOptionsErrors(t *testing.T) {
+	t.Parallel()
+	_, err := TLSOptions{CertPEM: []byte("nope"), KeyPEM: []byte("nope")}.Config()
+	assert.NotNil(t, err)
+	_, err = TLSOptions{CAPEM: []byte("nope")}.Config()
+	assert.NotNil(t, err)
+	_, err = TLSOptions{CertFile: "/does/not/exist.crt", KeyFile: "/does/not/exist.key"}.Config()
+	assert.NotNil(t, err)
+
+	cfg, err := TLSOptions{CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}}.Config()
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, cfg.CipherSuites)
+}
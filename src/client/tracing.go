@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// W3C trace context headers, see https://www.w3.org/TR/trace-context/
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+var errInvalidTraceparent = errors.New("invalid traceparent header")
+
+// SpanContext identifies a span within a trace, and is what gets propagated to the server in the traceparent and
+// tracestate headers
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+	// opaque vendor specific data, forwarded as-is
+	TraceState string
+}
+
+// a span context is valid if neither of its ids is all zeroes
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// renders the span context as a version 00 traceparent header value
+func (sc SpanContext) Traceparent() string {
+	flags := 0
+	if sc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// parses traceparent and tracestate header values, e.g. the ones on an incoming request, into a SpanContext
+func ParseTraceparent(traceparent string, tracestate string) (SpanContext, error) {
+	traceparent = strings.TrimSpace(traceparent)
+	parts := strings.Split(traceparent, "-")
+	// later versions may append fields, version 00 may not
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 || strings.ToLower(traceparent) != traceparent {
+		return SpanContext{}, errInvalidTraceparent
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&1 == 1
+	sc.TraceState = strings.TrimSpace(tracestate)
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// tags a context with the span that requests made with it are children of. pass the result to SendWithCtx, or to
+// any of the accounts ...WithCtx functions
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// returns the span context a context was tagged with, if any
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// Span is a single timed operation within a trace
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value interface{})
+	// records a point in time within the span, e.g. "dns_done"
+	AddEvent(name string)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans. the built-in one from NewTracer is enough to propagate trace context and hand finished spans
+// to an exporter; consumers on opentelemetry or similar can wrap their own tracer in this interface instead. Start
+// should derive the new span from the span context in ctx, if any, and return ctx tagged with the new span
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// a finished span, as handed to the export function of NewTracer
+type SpanData struct {
+	Name        string
+	SpanContext SpanContext
+	// invalid when the span is the root of its trace
+	Parent     SpanContext
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Events     []SpanEvent
+	Err        error
+}
+
+type SpanEvent struct {
+	Name string
+	Time time.Time
+}
+
+// constructor method for the built-in Tracer, which hands every finished span to export. spans without a parent in
+// the context start a new, sampled, trace
+func NewTracer(export func(SpanData)) Tracer {
+	return &tracer{export: export}
+}
+
+type tracer struct {
+	export func(SpanData)
+}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &span{tracer: t, data: SpanData{Name: name, Start: time.Now(), Attributes: make(map[string]interface{})}}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		s.data.Parent = parent
+		s.data.SpanContext = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled, TraceState: parent.TraceState}
+	} else {
+		rand.Read(s.data.SpanContext.TraceID[:])
+		s.data.SpanContext.Sampled = true
+	}
+	rand.Read(s.data.SpanContext.SpanID[:])
+	return ContextWithSpanContext(ctx, s.data.SpanContext), s
+}
+
+// httptrace callbacks can fire from other goroutines, even after the request is done, hence the lock
+type span struct {
+	tracer *tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+}
+
+func (s *span) AddEvent(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Events = append(s.data.Events, SpanEvent{Name: name, Time: time.Now()})
+	}
+}
+
+func (s *span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Err = err
+	}
+}
+
+func (s *span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	if s.tracer.export != nil {
+		s.tracer.export(data)
+	}
+}
+
+// built-in middleware that wraps every attempt in a span with http.method, http.url, http.status_code and
+// http.retry_count attributes, injects the traceparent/tracestate headers for the span, and records connection
+// timings (dns, connect, tls, first byte) as span events
+func TracingMiddleware(t Tracer) Middleware {
+	fields := redactionSet(nil)
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, span := t.Start(req.Context(), "HTTP "+req.Method)
+			defer span.End()
+
+			attempt := AttemptFromContext(ctx)
+			span.SetAttribute("http.method", req.Method)
+			span.SetAttribute("http.url", redactURL(req.URL, fields))
+			span.SetAttribute("http.attempt", attempt)
+			span.SetAttribute("http.retry_count", attempt-1)
+			if operation := OperationFromContext(ctx); operation != "" {
+				span.SetAttribute("accounts.operation", operation)
+			}
+
+			req = req.Clone(httptrace.WithClientTrace(ctx, clientTrace(span)))
+			sc := span.SpanContext()
+			req.Header.Set(TraceparentHeader, sc.Traceparent())
+			if sc.TraceState != "" {
+				req.Header.Set(TracestateHeader, sc.TraceState)
+			} else {
+				req.Header.Del(TracestateHeader)
+			}
+
+			resp, err := next.Do(req)
+			if err != nil {
+				span.RecordError(err)
+				return resp, err
+			}
+			span.SetAttribute("http.status_code", resp.StatusCode)
+			return resp, err
+		})
+	}
+}
+
+// records the connection level timings of a request on its span
+func clientTrace(span Span) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(hostPort string) { span.AddEvent("get_conn") },
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.SetAttribute("net.conn_reused", info.Reused)
+			span.AddEvent("got_conn")
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { span.AddEvent("dns_start") },
+		DNSDone:              func(httptrace.DNSDoneInfo) { span.AddEvent("dns_done") },
+		ConnectStart:         func(network, addr string) { span.AddEvent("connect_start") },
+		ConnectDone:          func(network, addr string, err error) { span.AddEvent("connect_done") },
+		TLSHandshakeStart:    func() { span.AddEvent("tls_handshake_start") },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { span.AddEvent("tls_handshake_done") },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { span.AddEvent("wrote_request") },
+		GotFirstResponseByte: func() { span.AddEvent("first_response_byte") },
+	}
+}
+
+// traces every request sent through the default client
+func SetTracer(t Tracer) {
+	DefaultClient.SetTracer(t)
+}
+
+// traces every request sent through a client struct. this registers a TracingMiddleware, so it should be called once,
+// before the client is shared between goroutines
+func (c *Client) SetTracer(t Tracer) {
+	c.Use(TracingMiddleware(t))
+}
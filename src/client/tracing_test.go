@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helper that collects the spans a tracer exports
+type exportedSpans struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *exportedSpans) export(data SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, data)
+}
+
+func (e *exportedSpans) get() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+func eventNames(data SpanData) []string {
+	var names []string
+	for _, event := range data.Events {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+// tracing-test-1 - traceparent headers should round trip, and malformed ones should be rejected
+func TestParseTraceparent(t *testing.T) {
+	t.Parallel()
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(header, "congo=t61rcWkgMzE")
+	assert.Nil(t, err)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "congo=t61rcWkgMzE", sc.TraceState)
+	assert.Equal(t, header, sc.Traceparent())
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := ParseTraceparent(invalid, "")
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+// tracing-test-2 - every attempt gets a span that is a child of the span in the caller's context, and the server
+// sees the attempt's own span in the traceparent header
+func TestTracingMiddleware(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	var mu sync.Mutex
+	var received []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		received = append(received, req.Header.Get(TraceparentHeader), req.Header.Get(TracestateHeader))
+		mu.Unlock()
+		if atomic.AddInt32(&calls, 1) == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer mockServer.Close()
+
+	exported := &exportedSpans{}
+	c := NewDefaultClient()
+	c.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, RetryableStatusCodes: map[int]bool{http.StatusServiceUnavailable: true}, RetryableMethods: map[string]bool{http.MethodGet: true}})
+	c.SetTracer(NewTracer(exported.export))
+
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "congo=t61rcWkgMzE")
+	assert.Nil(t, err)
+	ctx := WithOperation(ContextWithSpanContext(context.Background(), parent), "fetch")
+	resp, err := c.SendWithCtx(ctx, Request{Method: http.MethodGet, BaseURL: mockServer.URL + "?filter[iban]=GB11NWBK40030041426819"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	spans := exported.get()
+	assert.Len(t, spans, 2)
+	for i, data := range spans {
+		assert.Equal(t, "HTTP GET", data.Name)
+		assert.Equal(t, parent.TraceID, data.SpanContext.TraceID)
+		assert.Equal(t, parent.SpanID, data.Parent.SpanID)
+		assert.NotEqual(t, parent.SpanID, data.SpanContext.SpanID)
+		assert.Equal(t, http.MethodGet, data.Attributes["http.method"])
+		assert.Equal(t, mockServer.URL+"?filter%5Biban%5D=%5BREDACTED%5D", data.Attributes["http.url"])
+		assert.Equal(t, i, data.Attributes["http.retry_count"])
+		assert.Equal(t, "fetch", data.Attributes["accounts.operation"])
+		assert.Contains(t, eventNames(data), "got_conn")
+		assert.Contains(t, eventNames(data), "first_response_byte")
+		assert.Equal(t, data.SpanContext.Traceparent(), received[2*i])
+		assert.Equal(t, "congo=t61rcWkgMzE", received[2*i+1])
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, spans[0].Attributes["http.status_code"])
+	assert.Equal(t, http.StatusOK, spans[1].Attributes["http.status_code"])
+	assert.Contains(t, eventNames(spans[0]), "connect_done")
+	assert.Equal(t, true, spans[1].Attributes["net.conn_reused"])
+}
+
+// tracing-test-3 - without a span in the context, each request starts a new sampled trace
+func TestTracingMiddlewareStartsNewTrace(t *testing.T) {
+	t.Parallel()
+	var traceparent string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		traceparent = req.Header.Get(TraceparentHeader)
+	}))
+	defer mockServer.Close()
+
+	exported := &exportedSpans{}
+	c := NewDefaultClient()
+	c.SetTracer(NewTracer(exported.export))
+	_, err := c.Send(Request{Method: http.MethodGet, BaseURL: mockServer.URL})
+	assert.Nil(t, err)
+
+	spans := exported.get()
+	assert.Len(t, spans, 1)
+	assert.False(t, spans[0].Parent.IsValid())
+	sc, err := ParseTraceparent(traceparent, "")
+	assert.Nil(t, err)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, spans[0].SpanContext.TraceID, sc.TraceID)
+}
+
+// tracing-test-4 - a transport error should be recorded on the span
+func TestTracingMiddlewareRecordsErrors(t *testing.T) {
+	t.Parallel()
+	exported := &exportedSpans{}
+	c := NewDefaultClient()
+	c.SetTracer(NewTracer(exported.export))
+	_, err := c.Send(Request{Method: http.MethodGet, BaseURL: "http://127.0.0.1:1"})
+	assert.NotNil(t, err)
+
+	spans := exported.get()
+	assert.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Err)
+	assert.NotContains(t, spans[0].Attributes, "http.status_code")
+}
@@ -1,7 +1,116 @@
+// typed errors for non-2xx responses coming back from the form3 backend api.
+// See https://api-docs.form3.tech/api.html#introduction-and-api-conventions-errors for
+// more information about the error payload.
 package errors
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HttpErrorCode classifies a failed http call. every code is also an error value, so the constants below double up
+// as sentinels that can be matched with errors.Is() from the golang stl
 type HttpErrorCode int
 
 const (
 	_ = HttpErrorCode(iota)
+	ErrBadRequest
+	ErrNotFound
+	ErrConflict
+	ErrRateLimited
+	ErrServerError
+	ErrUnexpectedStatus
+	// a 409 caused by a stale resource version rather than a duplicate. it also matches ErrConflict
+	ErrVersionConflict
 )
+
+// header the form3 api uses to correlate a request with its server side logs
+const RequestIDHeader = "X-Request-Id"
+
+func (c HttpErrorCode) Error() string {
+	switch c {
+	case ErrBadRequest:
+		return "bad request"
+	case ErrNotFound:
+		return "not found"
+	case ErrConflict:
+		return "conflict"
+	case ErrRateLimited:
+		return "rate limited"
+	case ErrServerError:
+		return "server error"
+	case ErrVersionConflict:
+		return "version conflict"
+	default:
+		return "unexpected status"
+	}
+}
+
+// a version conflict is a more specific kind of conflict, so errors.Is(err, ErrConflict) holds for both
+func (c HttpErrorCode) Is(target error) bool {
+	return c == ErrVersionConflict && target == ErrConflict
+}
+
+// APIError is returned by the accounts package for any response outside of the 2xx range. the ErrorMessage and
+// ErrorCode fields are decoded from the form3 error payload when the backend sends one
+type APIError struct {
+	StatusCode   int           `json:"-"`
+	Code         HttpErrorCode `json:"-"`
+	ErrorMessage string        `json:"error_message"`
+	ErrorCode    string        `json:"error_code"`
+	Method       string        `json:"-"`
+	URL          string        `json:"-"`
+	RequestID    string        `json:"-"`
+	Body         string        `json:"-"`
+}
+
+// builds an APIError out of the raw pieces of a http response. a body that isn't a form3 error payload is not an error
+// in itself, we still keep it around in APIError.Body for the caller to inspect
+func NewAPIError(method string, url string, statusCode int, headers http.Header, body []byte) *APIError {
+	apiErr := &APIError{}
+	_ = json.Unmarshal(body, apiErr)
+
+	apiErr.StatusCode = statusCode
+	apiErr.Code = CodeFromStatus(statusCode)
+	apiErr.Method = method
+	apiErr.URL = url
+	apiErr.RequestID = headers.Get(RequestIDHeader)
+	apiErr.Body = string(body)
+
+	return apiErr
+}
+
+// maps a http status code to one of our HttpErrorCode sentinels
+func CodeFromStatus(statusCode int) HttpErrorCode {
+	switch {
+	case statusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusConflict:
+		return ErrConflict
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return ErrServerError
+	default:
+		return ErrUnexpectedStatus
+	}
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Code.Error())
+	if e.ErrorMessage != "" {
+		msg += ": " + e.ErrorMessage
+	}
+	if e.RequestID != "" {
+		msg += " (request id " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// lets errors.Is(err, ErrNotFound) and friends see through an *APIError
+func (e *APIError) Unwrap() error {
+	return e.Code
+}
@@ -1,6 +1,9 @@
 package errors
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,3 +12,77 @@ import (
 func TestDummy(t *testing.T) {
 	assert.True(t, true, true)
 }
+
+// unit-test-1 - make sure status codes are mapped onto the right sentinel
+func TestCodeFromStatus(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		status   int
+		expected HttpErrorCode
+	}{
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, ErrServerError},
+		{http.StatusBadGateway, ErrServerError},
+		{http.StatusForbidden, ErrUnexpectedStatus},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, CodeFromStatus(test.status), "wrong code for status %d", test.status)
+	}
+}
+
+// unit-test-2 - decode a canonical form3 error payload into an APIError
+func TestNewAPIError(t *testing.T) {
+	t.Parallel()
+	headers := http.Header{}
+	headers.Set(RequestIDHeader, "abc-123")
+	body := []byte(`{"error_message": "Account cannot be created as it violates a duplicate constraint", "error_code": "e1c9b9ae-1cb2-4a22-ae8c-1d4bb5f1e2c6"}`)
+
+	apiErr := NewAPIError(http.MethodPost, "http://superfake.com/v1/organisation/accounts", http.StatusConflict, headers, body)
+
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, ErrConflict, apiErr.Code)
+	assert.Equal(t, "Account cannot be created as it violates a duplicate constraint", apiErr.ErrorMessage)
+	assert.Equal(t, "e1c9b9ae-1cb2-4a22-ae8c-1d4bb5f1e2c6", apiErr.ErrorCode)
+	assert.Equal(t, "abc-123", apiErr.RequestID)
+	assert.Equal(t, string(body), apiErr.Body)
+	assert.Contains(t, apiErr.Error(), "409")
+	assert.Contains(t, apiErr.Error(), "duplicate constraint")
+}
+
+// unit-test-3 - a body that isn't json should still produce a usable error
+func TestNewAPIErrorNonJSONBody(t *testing.T) {
+	t.Parallel()
+	apiErr := NewAPIError(http.MethodGet, "http://superfake.com", http.StatusBadGateway, http.Header{}, []byte("<html>bad gateway</html>"))
+	assert.Equal(t, ErrServerError, apiErr.Code)
+	assert.Empty(t, apiErr.ErrorMessage)
+	assert.Equal(t, "<html>bad gateway</html>", apiErr.Body)
+}
+
+// unit-test-4 - sentinels should be reachable with errors.Is and the concrete type with errors.As, even when wrapped
+func TestAPIErrorIsAndAs(t *testing.T) {
+	t.Parallel()
+	var err error = NewAPIError(http.MethodGet, "http://superfake.com", http.StatusNotFound, http.Header{}, nil)
+	wrapped := fmt.Errorf("fetching account: %w", err)
+
+	assert.True(t, errors.Is(wrapped, ErrNotFound))
+	assert.False(t, errors.Is(wrapped, ErrConflict))
+
+	var apiErr *APIError
+	assert.True(t, errors.As(wrapped, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+// unit-test-5 - a version conflict should match both its own sentinel and the broader ErrConflict
+func TestVersionConflictIsAConflict(t *testing.T) {
+	t.Parallel()
+	apiErr := NewAPIError(http.MethodPatch, "http://superfake.com", http.StatusConflict, http.Header{}, nil)
+	apiErr.Code = ErrVersionConflict
+
+	assert.True(t, errors.Is(apiErr, ErrVersionConflict))
+	assert.True(t, errors.Is(apiErr, ErrConflict))
+	assert.False(t, errors.Is(NewAPIError(http.MethodPost, "http://superfake.com", http.StatusConflict, http.Header{}, nil), ErrVersionConflict))
+	assert.Contains(t, apiErr.Error(), "version conflict")
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"github.com/sarabrajsingh/interview-accountapi/src/accounts"
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
 	"github.com/sarabrajsingh/interview-accountapi/src/models"
 	"github.com/google/uuid"
 )
 
 func main() {
 
+	// log every request, with account holder details redacted from the bodies
+	client.Use(client.LoggingMiddleware(client.NewStdLogger(log.Default()), client.LoggingOptions{LogBodies: true}))
+
 	// some default and required variables
 	AccountClassification := "Personal"
 	AccountMatchingOptOut := false
@@ -49,21 +54,22 @@ func main() {
 		Data: &accountData,
 	}
 
-	resp, err := accounts.Create(account)
+	created, resp, err := accounts.Create(account)
 	if err != nil {
 		fmt.Println(err)
+		return
 	}
-	fmt.Println(resp)
+	fmt.Println(resp.StatusCode, created.Data.ID, *created.Data.Version)
 
-	resp, err = accounts.Fetch(account_id)
+	fetched, resp, err := accounts.Fetch(account_id)
 	if err != nil {
 		fmt.Println(err)
+		return
 	}
-	fmt.Println(resp)
+	fmt.Println(resp.StatusCode, fetched.Data.ID, *fetched.Data.Version)
 
-	resp, err = accounts.Delete(account_id, account_version)
+	err = accounts.Delete(account_id, account_version)
 	if err != nil {
 		fmt.Println(err)
 	}
-	fmt.Println(resp)
 }
@@ -3,18 +3,43 @@
 // more information about fields.
 package models
 
+import "time"
+
 type Account struct {
-	Data *AccountData
+	Data  *AccountData `json:"data"`
+	Links *Links       `json:"links,omitempty"`
 }
 
+// CreatedOn, ModifiedOn and Version are populated by the backend api. they are ignored on create
 type AccountData struct {
 	Attributes     *AccountAttributes `json:"attributes,omitempty"`
+	CreatedOn      *time.Time         `json:"created_on,omitempty"`
 	ID             string             `json:"id,omitempty"`
+	ModifiedOn     *time.Time         `json:"modified_on,omitempty"`
 	OrganisationID string             `json:"organisation_id,omitempty"`
 	Type           string             `json:"type,omitempty"`
 	Version        *int64             `json:"version,omitempty"`
 }
 
+// a single page of accounts, as returned by the list endpoint
+type AccountList struct {
+	Data  []AccountData `json:"data"`
+	Links *Links        `json:"links,omitempty"`
+	Meta  Meta          `json:"meta,omitempty"`
+}
+
+// free-form json:api meta object returned alongside a page of resources
+type Meta map[string]interface{}
+
+// json:api links returned alongside a resource or a page of resources
+type Links struct {
+	First string `json:"first,omitempty"`
+	Last  string `json:"last,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Self  string `json:"self,omitempty"`
+}
+
 type AccountAttributes struct {
 	AccountClassification   *string  `json:"account_classification,omitempty"`
 	AccountMatchingOptOut   *bool    `json:"account_matching_opt_out,omitempty"`
@@ -2,12 +2,16 @@ package integration
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/rand"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sarabrajsingh/interview-accountapi/src/accounts"
+	apierrors "github.com/sarabrajsingh/interview-accountapi/src/errors"
 	"github.com/sarabrajsingh/interview-accountapi/src/models"
 	"github.com/stretchr/testify/assert"
 )
@@ -65,9 +69,23 @@ func generateAccount() models.Account {
 	}
 }
 
+// blocks until the backend api is serving before any test runs, so the tests don't race the containers that
+// docker-compose is still starting up
+func TestMain(m *testing.M) {
+	accounts.DefaultUrl.SetBaseURL(os.Getenv("FORM3_ACCOUNTS_API_URL"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := accounts.WaitUntilReady(ctx, time.Second)
+	cancel()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
 /* INTEGRATION TESTS START HERE
   NOTES:
-- Take caution when modifying these tests. TestSetBaseURL change the Accounts.api.URL.BaseURL around
+- Take caution when modifying these tests. TestSetBaseURL change the Accounts.api.URL base URL around
   and this variable is leveraged in the Create(), Fetch() and Delete() methods that are also in that package. If they point to the wrong
   baseURL, the following integration tests will fail.
 - Preserve the ordering of the Integration tests as well, as they will break if the proper account(s) exist or don't exist in the API backend
@@ -79,20 +97,20 @@ func TestCreateValidAccount(t *testing.T) {
 	accounts.DefaultUrl.SetBaseURL(os.Getenv("FORM3_ACCOUNTS_API_URL"))
 	acc := generateAccount()
 
-	resp, err := accounts.Create(acc)
+	created, resp, err := accounts.Create(acc)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	assert.Equal(t, resp.StatusCode, 201, "failed to create new account against API")
-	assert.NotNil(t, resp.Body)
+	assert.Equal(t, account_id, created.Data.ID)
+	assert.NotNil(t, created.Data.Version)
+	assert.NotNil(t, created.Data.CreatedOn)
 }
 
 // IntegreationTest-2 - Try and recreate the same account from IntegrationTest-1. Except failure 409
 func TestCreateValidButDuplicatedAccount(t *testing.T) {
-	resp, err := accounts.Create(generateAccount())
-	if err != nil {
-		t.Error(err)
-	}
+	_, resp, err := accounts.Create(generateAccount())
+	assert.True(t, errors.Is(err, apierrors.ErrConflict), "expected a conflict error, got %v", err)
 	assert.Equal(t, resp.StatusCode, 409, "this test should have failed, as we are trying to duplicate an account_id against the API")
 	assert.NotNil(t, resp.Body)
 }
@@ -101,62 +119,55 @@ func TestCreateValidButDuplicatedAccount(t *testing.T) {
 func TestCreateInvalidAccount(t *testing.T) {
 	acc := generateAccount()
 	acc.Data.ID = "abc123"
-	resp, err := accounts.Create(acc)
-	if err != nil {
-		t.Error(err)
-	}
+	_, resp, err := accounts.Create(acc)
+	assert.True(t, errors.Is(err, apierrors.ErrBadRequest), "expected a bad request error, got %v", err)
 	assert.Equal(t, resp.StatusCode, 400, "failed to create bad account against API")
 	assert.NotNil(t, resp.Body)
 }
 
 // IntegrationTest-4 - fetch a valid account from the backend API
 func TestFetchValidAccount(t *testing.T) {
-	resp, err := accounts.Fetch(account_id)
+	fetched, resp, err := accounts.Fetch(account_id)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 	assert.Equal(t, resp.StatusCode, 200, "failed to GET resource from API backend")
+	assert.Equal(t, account_organisation_id, fetched.Data.OrganisationID)
 }
 
 // IntegrationTest-5 - try to fetch an invalid account from the backend API
 func TestFetchInvalidAccount(t *testing.T) {
-	resp, err := accounts.Fetch("superfake.com")
-	if err != nil {
-		t.Error(err)
-	}
+	_, resp, err := accounts.Fetch("superfake.com")
+	assert.True(t, errors.Is(err, apierrors.ErrBadRequest), "expected a bad request error, got %v", err)
 	assert.Equal(t, resp.StatusCode, 400, "failed to grab an invalid account")
 }
 
 // IntegrationTest-6 - delete a valid account from the backend API
 func TestDeleteValidAccount(t *testing.T) {
-	resp, err := accounts.Delete(account_id, account_version)
-	if err != nil {
-		t.Error(err)
-	}
-	assert.Equal(t, resp.StatusCode, 204, "failed to delete resource from API backend")
+	err := accounts.Delete(account_id, account_version)
+	assert.Nil(t, err, "failed to delete resource from API backend")
 }
 
 // IntegrationTest7 - create a valid account with a custom context
 func TestCreateValidAccountWithCtx(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
 	defer cancel()
-	resp, err := accounts.CreateWithCtx(ctx, generateAccount())
+	_, resp, err := accounts.CreateWithCtx(ctx, generateAccount())
 	if err != nil {
 		cancel()
-		t.Error(err)
+		t.Fatal(err)
 	}
 	assert.Equal(t, resp.StatusCode, 201, "failed to create new account against API")
-	assert.NotNil(t, resp.Body)
 }
 
 // IntegrationTest8 - fetch a valid account with a custom context
 func TestFetchValidAccountWithCtx(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
 	defer cancel()
-	resp, err := accounts.FetchWithCtx(ctx, account_id)
+	_, resp, err := accounts.FetchWithCtx(ctx, account_id)
 	if err != nil {
 		cancel()
-		t.Error(err)
+		t.Fatal(err)
 	}
 	assert.Equal(t, resp.StatusCode, 200, "failed to GET resource from API backend")
 }
@@ -165,32 +176,29 @@ func TestFetchValidAccountWithCtx(t *testing.T) {
 func TestDeleteValidAccountWithCtx(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
 	defer cancel()
-	resp, err := accounts.DeleteWithCtx(ctx, account_id, account_version)
+	err := accounts.DeleteWithCtx(ctx, account_id, account_version)
 	if err != nil {
 		cancel()
 		t.Error(err)
 	}
-	assert.Equal(t, resp.StatusCode, 204, "failed to delete resource from API backend")
 }
 
 // IntegrationTest10 - delete an ivalid account
 func TestDeleteInvalidAccount(t *testing.T) {
-	resp, err := accounts.Delete("superfake.com", 0)
-	if err != nil {
-		t.Error(err)
-	}
-	assert.Equal(t, resp.StatusCode, 400, "failed to delete an invalid account")
+	err := accounts.Delete("superfake.com", 0)
+	assert.True(t, errors.Is(err, apierrors.ErrBadRequest), "expected a bad request error, got %v", err)
 }
 
 // IntegrationTest11 - Test a bad context when trying to create an account
 func TestCreateValidAccountWithBadCtx(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond*1)
 	defer cancel()
-	resp, err := accounts.CreateWithCtx(ctx, generateAccount())
+	created, resp, err := accounts.CreateWithCtx(ctx, generateAccount())
 	if err == nil {
 		cancel()
 		t.Error("this error should have fired")
 	}
+	assert.Nil(t, created)
 	assert.Nil(t, resp)
 }
 
@@ -198,11 +206,12 @@ func TestCreateValidAccountWithBadCtx(t *testing.T) {
 func TestFetchValidAccountWithBadCtx(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond*1)
 	defer cancel()
-	resp, err := accounts.FetchWithCtx(ctx, account_id)
+	fetched, resp, err := accounts.FetchWithCtx(ctx, account_id)
 	if err == nil {
 		cancel()
 		t.Error("this error should have fired")
 	}
+	assert.Nil(t, fetched)
 	assert.Nil(t, resp)
 }
 
@@ -210,10 +219,41 @@ func TestFetchValidAccountWithBadCtx(t *testing.T) {
 func TestDeleteValidAccountWithBadCtx(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond*1)
 	defer cancel()
-	resp, err := accounts.DeleteWithCtx(ctx, account_id, account_version)
+	err := accounts.DeleteWithCtx(ctx, account_id, account_version)
 	if err == nil {
 		cancel()
 		t.Error("this error should have fired")
 	}
-	assert.Nil(t, resp)
+}
+
+// IntegrationTest14 - walk every page of accounts in the backend API
+func TestListAccounts(t *testing.T) {
+	it := accounts.List(accounts.ListOptions{PageSize: 10})
+	for it.Next() {
+		assert.NotEmpty(t, it.Account().Data.ID)
+	}
+	assert.Nil(t, it.Err())
+}
+
+// IntegrationTest15 - create-or-get should create the account once, and get it on every call after that
+func TestCreateOrGetAccount(t *testing.T) {
+	acc := generateAccount()
+	acc.Data.ID = uuid.New().String()
+
+	result, err := accounts.CreateOrGet(acc, accounts.CreateOrGetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.Created)
+
+	acc.Data.Attributes.BankID = randomAccountNumberGenerator(6)
+	result, err = accounts.CreateOrGet(acc, accounts.CreateOrGetOptions{Diff: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, result.Created)
+	assert.Equal(t, acc.Data.ID, result.Account.Data.ID)
+	assert.Len(t, result.Diff, 1)
+
+	assert.Nil(t, accounts.Delete(acc.Data.ID, int(*result.Account.Data.Version)))
 }
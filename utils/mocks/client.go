@@ -1,16 +1,265 @@
+// Package mocks holds test doubles for the http layer. MockClient stubs out http.Client.Do, so it can be handed to
+// client.NewClient (or set as a client's Doer) and a unit test never touches the network:
+//
+//	mock := mocks.NewMockClient()
+//	mock.On(http.MethodGet, "/v1/organisation/accounts/"+id).Return(http.StatusOK, body).Once()
+//	c := client.NewClient(mock)
+//	...
+//	mock.AssertExpectations(t)
 package mocks
 
-import "net/http"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// returned by Do for a request no expectation matches, when the mock has no DoFunc to fall back on
+var ErrUnexpectedCall = errors.New("mocks: unexpected call")
+
+// the subset of *testing.T the assertions need
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// a request the mock received
+type Call struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+	// the expectation that answered the call, nil if DoFunc did or nothing matched
+	Expectation *Expectation
+}
 
-// TO-DO implement a mock-client that emulated http.Client.Do for integration tets
+// MockClient is a stub http executor with the same Do as http.Client. every instance keeps its own expectations and
+// call log, and is safe to share between goroutines
 type MockClient struct {
+	// called for requests no expectation matches. nil means they fail with ErrUnexpectedCall
 	DoFunc func(req *http.Request) (*http.Response, error)
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+// constructor method for an empty mock client. a zero MockClient is ready to use as well
+func NewMockClient() *MockClient {
+	return &MockClient{}
+}
+
+// Expectation is a canned answer for the requests that match it. its setters return the expectation so they chain
+type Expectation struct {
+	mock   *MockClient
+	method string
+	path   string
+	match  func(req *http.Request) bool
+
+	status  int
+	header  http.Header
+	body    string
+	err     error
+	respond func(req *http.Request) (*http.Response, error)
+
+	// how many requests it may answer, zero for no limit
+	times int
+	calls int
+}
+
+// expects requests with method (empty for any) to path (empty for any). the expectation answers 200 with an empty
+// body until told otherwise. expectations are tried in the order they were added, and one that has answered all the
+// requests it was allowed to is skipped
+func (m *MockClient) On(method string, path string) *Expectation {
+	e := &Expectation{mock: m, method: method, path: path, status: http.StatusOK, header: http.Header{}}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// only matches requests the function also accepts, e.g. to check a header or the body
+func (e *Expectation) Matching(match func(req *http.Request) bool) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.match = match
+	return e
 }
 
+// answers with status and body
+func (e *Expectation) Return(status int, body string) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.status, e.body = status, body
+	return e
+}
+
+// adds a header to the answer
+func (e *Expectation) ReturnHeader(key string, value string) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.header.Add(key, value)
+	return e
+}
+
+// fails the request with err instead of answering it, like a transport error would
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.err = err
+	return e
+}
+
+// hands the request to a function to answer, for anything the canned answers can't do
+func (e *Expectation) RespondWith(respond func(req *http.Request) (*http.Response, error)) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.respond = respond
+	return e
+}
+
+// answers at most n requests, and AssertExpectations wants exactly n
+func (e *Expectation) Times(n int) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.times = n
+	return e
+}
+
+// same as Times(1)
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// how many requests the expectation answered so far
+func (e *Expectation) Calls() int {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	return e.calls
+}
+
+func (e *Expectation) String() string {
+	method, path := e.method, e.path
+	if method == "" {
+		method = "*"
+	}
+	if path == "" {
+		path = "*"
+	}
+	return method + " " + path
+}
+
+// whether the expectation matches a request and still has calls left. the lock must be held
+func (e *Expectation) accepts(req *http.Request) bool {
+	if e.times > 0 && e.calls >= e.times {
+		return false
+	}
+	if (e.method != "" && e.method != req.Method) || (e.path != "" && e.path != req.URL.Path) {
+		return false
+	}
+	return e.match == nil || e.match(req)
+}
+
+// records the request and answers it from the first expectation that matches, falling back to DoFunc
 func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
-	return GetDoFunc(req)
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	m.mu.Lock()
+	var matched *Expectation
+	for _, e := range m.expectations {
+		if e.accepts(req) {
+			matched = e
+			break
+		}
+	}
+	m.calls = append(m.calls, Call{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Header:      req.Header.Clone(),
+		Body:        body,
+		Expectation: matched,
+	})
+	if matched == nil {
+		doFunc := m.DoFunc
+		m.mu.Unlock()
+		if doFunc == nil {
+			return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedCall, req.Method, req.URL)
+		}
+		return doFunc(req)
+	}
+	matched.calls++
+	status, header, respBody, err, respond := matched.status, matched.header.Clone(), matched.body, matched.err, matched.respond
+	m.mu.Unlock()
+
+	if respond != nil {
+		return respond(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
 }
 
-var (
-	GetDoFunc func(req *http.Request) (*http.Response, error)
-)
+// every request the mock received so far, oldest first
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// reports every expectation that wasn't met: one with Times should have answered exactly that many requests, and
+// one without at least one. requests nothing matched are reported too, unless DoFunc answered them. returns true if
+// all was well
+func (m *MockClient) AssertExpectations(t TestingT) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ok := true
+	for _, e := range m.expectations {
+		if (e.times > 0 && e.calls != e.times) || (e.times == 0 && e.calls == 0) {
+			want := "at least 1"
+			if e.times > 0 {
+				want = fmt.Sprint(e.times)
+			}
+			t.Errorf("mocks: expected %s call(s) to %s, got %d", want, e, e.calls)
+			ok = false
+		}
+	}
+	if m.DoFunc == nil {
+		for _, call := range m.calls {
+			if call.Expectation == nil {
+				t.Errorf("mocks: unexpected call to %s %s", call.Method, call.URL)
+				ok = false
+			}
+		}
+	}
+	return ok
+}
+
+// forgets every expectation and every recorded call
+func (m *MockClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = nil
+	m.calls = nil
+}
@@ -0,0 +1,125 @@
+package mocks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/sarabrajsingh/interview-accountapi/src/client"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ client.Doer = (*MockClient)(nil)
+
+// records the failures an assertion reports, instead of failing the test
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// mocks-test-1 - a client built on the mock should get the canned answer, and the mock should record the request
+func TestMockClientExpectation(t *testing.T) {
+	t.Parallel()
+	mock := NewMockClient()
+	mock.On(http.MethodPost, "/v1/organisation/accounts").
+		Matching(func(req *http.Request) bool { return req.Header.Get("Content-Type") == "application/json" }).
+		Return(http.StatusCreated, `{"data": {}}`).
+		ReturnHeader("Location", "/v1/organisation/accounts/1").
+		Once()
+
+	c := client.NewClient(mock)
+	resp, err := c.Send(client.Request{
+		Method:  http.MethodPost,
+		BaseURL: "http://superfake.com/v1/organisation/accounts",
+		Body:    []byte(`{"data": {"id": "1"}}`),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"data": {}}`, resp.Body)
+	assert.Equal(t, "/v1/organisation/accounts/1", resp.Headers.Get("Location"))
+
+	calls := mock.Calls()
+	if assert.Len(t, calls, 1) {
+		assert.Equal(t, http.MethodPost, calls[0].Method)
+		assert.Equal(t, "http://superfake.com/v1/organisation/accounts", calls[0].URL)
+		assert.Equal(t, `{"data": {"id": "1"}}`, string(calls[0].Body))
+		assert.NotNil(t, calls[0].Expectation)
+	}
+	assert.True(t, mock.AssertExpectations(t))
+}
+
+// mocks-test-2 - expectations are tried in order, skipped once used up, and can fail like a transport would
+func TestMockClientOrdering(t *testing.T) {
+	t.Parallel()
+	mock := NewMockClient()
+	mock.On(http.MethodGet, "/health").Return(http.StatusServiceUnavailable, "").Times(2)
+	mock.On(http.MethodGet, "/health").Return(http.StatusOK, `{"status": "up"}`)
+	broken := errors.New("connection reset")
+	mock.On(http.MethodDelete, "").ReturnError(broken)
+
+	c := client.NewClient(mock)
+	var statuses []int
+	for i := 0; i < 3; i++ {
+		resp, err := c.Send(client.Request{Method: http.MethodGet, BaseURL: "http://superfake.com/health"})
+		assert.Nil(t, err)
+		statuses = append(statuses, resp.StatusCode)
+	}
+	assert.Equal(t, []int{503, 503, 200}, statuses)
+
+	_, err := c.Send(client.Request{Method: http.MethodDelete, BaseURL: "http://superfake.com/anything"})
+	assert.ErrorIs(t, err, broken)
+	assert.True(t, mock.AssertExpectations(t))
+}
+
+// mocks-test-3 - unmet expectations and unexpected calls should both be reported, unless DoFunc handles the latter
+func TestMockClientAssertExpectations(t *testing.T) {
+	t.Parallel()
+	mock := NewMockClient()
+	mock.On(http.MethodGet, "/a").Once()
+	mock.On(http.MethodGet, "/b")
+
+	c := client.NewClient(mock)
+	_, err := c.Send(client.Request{Method: http.MethodGet, BaseURL: "http://superfake.com/c"})
+	assert.ErrorIs(t, err, ErrUnexpectedCall)
+
+	recorder := &recordingT{}
+	assert.False(t, mock.AssertExpectations(recorder))
+	assert.Equal(t, []string{
+		"mocks: expected 1 call(s) to GET /a, got 0",
+		"mocks: expected at least 1 call(s) to GET /b, got 0",
+		"mocks: unexpected call to GET http://superfake.com/c",
+	}, recorder.errors)
+
+	mock.Reset()
+	mock.DoFunc = func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("fallback")
+	}
+	_, err = c.Send(client.Request{Method: http.MethodGet, BaseURL: "http://superfake.com/c"})
+	assert.EqualError(t, err, "fallback")
+	assert.True(t, mock.AssertExpectations(t))
+}
+
+// mocks-test-4 - one mock can be shared by concurrent requests, with every call counted
+func TestMockClientConcurrency(t *testing.T) {
+	t.Parallel()
+	mock := NewMockClient()
+	expectation := mock.On("", "").Return(http.StatusNoContent, "")
+	c := client.NewClient(mock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Send(client.Request{Method: http.MethodGet, BaseURL: "http://superfake.com/"})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, expectation.Calls())
+	assert.Len(t, mock.Calls(), 50)
+}